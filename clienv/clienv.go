@@ -19,16 +19,17 @@ func sanitizeName(name string) string {
 }
 
 type CliEnv struct {
-	stdout         io.Writer
-	stderr         io.Writer
-	Path           *PathStructure
-	authURL        string
-	graphqlURL     string
-	branch         string
-	nhclient       *nhostclient.Client
-	nhpublicclient *nhostclient.Client
-	projectName    string
-	localSubdomain string
+	stdout           io.Writer
+	stderr           io.Writer
+	Path             *PathStructure
+	authURL          string
+	graphqlURL       string
+	branch           string
+	nhclient         *nhostclient.Client
+	nhpublicclient   *nhostclient.Client
+	projectName      string
+	localSubdomain   string
+	containerRuntime string
 }
 
 func New(
@@ -42,16 +43,17 @@ func New(
 	localSubdomain string,
 ) *CliEnv {
 	return &CliEnv{
-		stdout:         stdout,
-		stderr:         stderr,
-		Path:           path,
-		authURL:        authURL,
-		graphqlURL:     graphqlURL,
-		branch:         branch,
-		nhclient:       nil,
-		nhpublicclient: nil,
-		projectName:    projectName,
-		localSubdomain: localSubdomain,
+		stdout:           stdout,
+		stderr:           stderr,
+		Path:             path,
+		authURL:          authURL,
+		graphqlURL:       graphqlURL,
+		branch:           branch,
+		nhclient:         nil,
+		nhpublicclient:   nil,
+		projectName:      projectName,
+		localSubdomain:   localSubdomain,
+		containerRuntime: "auto",
 	}
 }
 
@@ -70,13 +72,14 @@ func FromCLI(cCtx *cli.Context) *CliEnv {
 			cCtx.String(flagDotNhostFolder),
 			cCtx.String(flagNhostFolder),
 		),
-		authURL:        cCtx.String(flagAuthURL),
-		graphqlURL:     cCtx.String(flagGraphqlURL),
-		branch:         cCtx.String(flagBranch),
-		projectName:    sanitizeName(cCtx.String(flagProjectName)),
-		nhclient:       nil,
-		nhpublicclient: nil,
-		localSubdomain: cCtx.String(flagLocalSubdomain),
+		authURL:          cCtx.String(flagAuthURL),
+		graphqlURL:       cCtx.String(flagGraphqlURL),
+		branch:           cCtx.String(flagBranch),
+		projectName:      sanitizeName(cCtx.String(flagProjectName)),
+		nhclient:         nil,
+		nhpublicclient:   nil,
+		localSubdomain:   cCtx.String(flagLocalSubdomain),
+		containerRuntime: cCtx.String(flagContainerRuntime),
 	}
 }
 
@@ -88,6 +91,12 @@ func (ce *CliEnv) LocalSubdomain() string {
 	return ce.localSubdomain
 }
 
+// ContainerRuntime returns the name of the container runtime to use, "auto" if
+// it should be detected.
+func (ce *CliEnv) ContainerRuntime() string {
+	return ce.containerRuntime
+}
+
 func (ce *CliEnv) AuthURL() string {
 	return ce.authURL
 }
@@ -71,6 +71,14 @@ func (p PathStructure) DockerCompose() string {
 	return filepath.Join(p.dotNhostFolder, "docker-compose.yaml")
 }
 
+func (p PathStructure) HasuraChecksum() string {
+	return filepath.Join(p.dotNhostFolder, "hasura.sum")
+}
+
+func (p PathStructure) Snapshots(branch string) string {
+	return filepath.Join(p.dotNhostFolder, "snapshots", sanitizeName(branch))
+}
+
 func (p PathStructure) Functions() string {
 	return filepath.Join(p.root, "functions")
 }
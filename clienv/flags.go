@@ -4,22 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/urfave/cli/v2"
 )
 
 const (
-	flagAuthURL        = "auth-url"
-	flagGraphqlURL     = "graphql-url"
-	flagBranch         = "branch"
-	flagProjectName    = "project-name"
-	flagRootFolder     = "root-folder"
-	flagNhostFolder    = "nhost-folder"
-	flagDotNhostFolder = "dot-nhost-folder"
-	flagLocalSubdomain = "local-subdomain"
+	flagAuthURL          = "auth-url"
+	flagGraphqlURL       = "graphql-url"
+	flagBranch           = "branch"
+	flagProjectName      = "project-name"
+	flagRootFolder       = "root-folder"
+	flagNhostFolder      = "nhost-folder"
+	flagDotNhostFolder   = "dot-nhost-folder"
+	flagLocalSubdomain   = "local-subdomain"
+	flagContainerRuntime = "container-runtime"
 )
 
+//nolint:gochecknoglobals
+var containerRuntimes = []string{"auto", "docker", "podman", "nerdctl"}
+
 func getGitBranchName() string {
 	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{
 		DetectDotGit:          true,
@@ -37,6 +44,42 @@ func getGitBranchName() string {
 	return head.Name().Short()
 }
 
+// GitBranches returns the local and remote branches of the git repository in the
+// current directory. Remote branches are returned without the remote prefix.
+func GitBranches() ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git references: %w", err)
+	}
+	defer refs.Close()
+
+	branches := make([]string, 0)
+	if err := refs.ForEach(func(ref *plumbing.Reference) error {
+		switch {
+		case ref.Name().IsBranch():
+			branches = append(branches, ref.Name().Short())
+		case ref.Name().IsRemote():
+			if _, branch, ok := strings.Cut(ref.Name().Short(), "/"); ok && branch != "HEAD" {
+				branches = append(branches, branch)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list git branches: %w", err)
+	}
+
+	return branches, nil
+}
+
 func Flags() ([]cli.Flag, error) {
 	fullWorkingDir, err := os.Getwd()
 	if err != nil {
@@ -104,5 +147,21 @@ func Flags() ([]cli.Flag, error) {
 			Value:   "local",
 			EnvVars: []string{"NHOST_LOCAL_SUBDOMAIN"},
 		},
+		&cli.StringFlag{ //nolint:exhaustruct
+			Name:    flagContainerRuntime,
+			Usage:   "Container runtime used to run the development environment (auto, docker, podman, nerdctl). If set to auto, it is detected from DOCKER_HOST and the binaries available", //nolint:lll
+			Value:   "auto",
+			EnvVars: []string{"NHOST_CONTAINER_RUNTIME"},
+			Action: func(_ *cli.Context, runtime string) error {
+				if !slices.Contains(containerRuntimes, runtime) {
+					return fmt.Errorf( //nolint:err113
+						"invalid container runtime %q, valid values are: %s",
+						runtime, strings.Join(containerRuntimes, ", "),
+					)
+				}
+
+				return nil
+			},
+		},
 	}, nil
 }
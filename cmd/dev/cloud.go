@@ -205,6 +205,7 @@ func cloud( //nolint:funlen
 		dashboardVersion,
 		configserverImage,
 		caCertificatesPath,
+		dc.Runtime(),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to generate docker-compose.yaml: %w", err)
@@ -235,7 +236,7 @@ func cloud( //nolint:funlen
 		return err
 	}
 
-	docker := dockercompose.NewDocker()
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
 
 	ce.Infoln("Downloading metadata...")
 
@@ -289,7 +290,12 @@ func Cloud(
 	proj *graphql.AppSummaryFragment,
 	postgresURL string,
 ) error {
-	dc := dockercompose.New(ce.Path.WorkingDir(), ce.Path.DockerCompose(), ce.ProjectName())
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
 
 	if err := cloud(
 		ctx,
@@ -19,7 +19,12 @@ func CommandCompose() *cli.Command {
 
 func commandCompose(cCtx *cli.Context) error {
 	ce := clienv.FromCLI(cCtx)
-	dc := dockercompose.New(ce.Path.WorkingDir(), ce.Path.DockerCompose(), ce.ProjectName())
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
 
 	return dc.Wrapper(cCtx.Context, cCtx.Args().Slice()...) //nolint:wrapcheck
 }
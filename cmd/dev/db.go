@@ -0,0 +1,341 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagForce = "force"
+	flagYes   = "yes"
+)
+
+const snapshotExtension = ".dump"
+
+var snapshotNameRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`) //nolint:gochecknoglobals
+
+func CommandDB() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "db",
+		Aliases: []string{},
+		Usage:   "Manage the database of the local development environment",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "snapshot",
+				Usage:     "Save a snapshot of the database. Snapshots are stored per branch under .nhost/snapshots",
+				ArgsUsage: "[name]",
+				Action:    commandDBSnapshot,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:  flagForce,
+						Usage: "Overwrite the snapshot if it already exists",
+						Value: false,
+					},
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore a snapshot of the database, replacing it. Services using the database are stopped meanwhile",
+				ArgsUsage: "<name>",
+				Action:    commandDBRestore,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:    flagYes,
+						Usage:   "Skip confirmation",
+						EnvVars: []string{"NHOST_YES"},
+					},
+				},
+			},
+			{
+				Name:   "list",
+				Usage:  "List snapshots of the current branch",
+				Action: commandDBList,
+			},
+		},
+	}
+}
+
+func snapshotPath(ce *clienv.CliEnv, name string) (string, error) {
+	if !snapshotNameRe.MatchString(name) {
+		return "", fmt.Errorf( //nolint:err113
+			"invalid snapshot name %q, only letters, numbers, '.', '_' and '-' are allowed", name,
+		)
+	}
+
+	return filepath.Join(ce.Path.Snapshots(ce.Branch()), name+snapshotExtension), nil
+}
+
+func commandDBSnapshot(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	name := cCtx.Args().First()
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+
+	path, err := snapshotPath(ce, name)
+	if err != nil {
+		return err
+	}
+
+	if clienv.PathExists(path) && !cCtx.Bool(flagForce) {
+		return fmt.Errorf( //nolint:err113
+			"snapshot %s already exists, use --%s to overwrite it", name, flagForce,
+		)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to create snapshots folder: %w", err)
+	}
+
+	// dump to a temporary file so a failed dump doesn't leave a broken snapshot behind
+	f, err := os.CreateTemp(filepath.Dir(path), "."+name+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	ce.Infoln("Creating snapshot %s...", name)
+
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
+	if err := dc.Exec(
+		cCtx.Context,
+		nil,
+		f,
+		"postgres",
+		"pg_dump", "-U", "postgres", "-d", "local", "--format=custom",
+	); err != nil {
+		return fmt.Errorf("failed to dump database: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	ce.Infoln("Snapshot saved to %s", path)
+
+	return nil
+}
+
+func commandDBRestore(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	name := cCtx.Args().First()
+	if name == "" {
+		return errors.New("snapshot name is required") //nolint:err113
+	}
+
+	path, err := snapshotPath(ce, name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf( //nolint:err113
+			"snapshot %s not found, run `nhost dev db list` to see available snapshots", name,
+		)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	if !cCtx.Bool(flagYes) {
+		ce.PromptMessage(
+			"This will replace the contents of the database with snapshot %s. Continue? [y/N] ", name,
+		)
+
+		resp, err := ce.PromptInput(false)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		if resp != "y" && resp != "Y" {
+			return nil
+		}
+	}
+
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
+
+	running, err := dc.RunningServices(cCtx.Context)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if !slices.Contains(running, "postgres") {
+		return errors.New("postgres is not running, start the environment with `nhost up`") //nolint:err113
+	}
+
+	// graphql, auth, storage, etc. hold connections and locks on the database
+	ce.Infoln("Stopping the services using the database...")
+
+	if err := dc.StopServices(
+		cCtx.Context,
+		slices.DeleteFunc(running, func(s string) bool { return s == "postgres" })...,
+	); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	ce.Infoln("Restoring snapshot %s...", name)
+
+	restoreErr := restoreDatabase(cCtx.Context, dc, f)
+
+	// start the services even if the restore failed so the environment is usable
+	ce.Infoln("Starting services...")
+
+	if err := dc.Start(cCtx.Context); err != nil {
+		return errors.Join(restoreErr, err)
+	}
+
+	if restoreErr != nil {
+		return restoreErr
+	}
+
+	// migrations applied after the snapshot was taken are missing now
+	if err := resetHasuraChecksum(ce); err != nil {
+		return err
+	}
+
+	ce.Infoln("Reloading metadata...")
+
+	if err := dc.ReloadMetadata(cCtx.Context); err != nil {
+		return fmt.Errorf("failed to reload metadata: %w", err)
+	}
+
+	ce.Infoln("Snapshot %s restored", name)
+
+	return nil
+}
+
+// restoreDatabase replaces the local database with the snapshot read from r.
+// The database is recreated rather than cleaned so objects created after the
+// snapshot was taken, i.e. by newer migrations, don't survive.
+func restoreDatabase(ctx context.Context, dc *dockercompose.DockerCompose, r io.Reader) error {
+	if err := dc.Exec(
+		ctx,
+		nil,
+		os.Stdout,
+		"postgres",
+		"psql",
+		"-U", "postgres",
+		"-d", "postgres",
+		"-v", "ON_ERROR_STOP=1",
+		"-c", "DROP DATABASE IF EXISTS local WITH (FORCE)",
+	); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+
+	// --create recreates the database with the properties it had in the snapshot
+	if err := dc.Exec(
+		ctx,
+		r,
+		os.Stdout,
+		"postgres",
+		"pg_restore",
+		"-U", "postgres",
+		"-d", "postgres",
+		"--create",
+		"--exit-on-error",
+	); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	return nil
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func commandDBList(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	entries, err := os.ReadDir(ce.Path.Snapshots(ce.Branch()))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read snapshots folder: %w", err)
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), snapshotExtension) ||
+			strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot %s: %w", e.Name(), err)
+		}
+
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(a, b fs.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+
+	name := clienv.Column{
+		Header: "Name",
+		Rows:   make([]string, 0),
+	}
+	size := clienv.Column{
+		Header: "Size",
+		Rows:   make([]string, 0),
+	}
+	created := clienv.Column{
+		Header: "Created",
+		Rows:   make([]string, 0),
+	}
+
+	for _, info := range infos {
+		name.Rows = append(name.Rows, strings.TrimSuffix(info.Name(), snapshotExtension))
+		size.Rows = append(size.Rows, humanSize(info.Size()))
+		created.Rows = append(created.Rows, info.ModTime().Format(time.RFC3339))
+	}
+
+	ce.Println("%s", clienv.Table(name, size, created))
+
+	return nil
+}
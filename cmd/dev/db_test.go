@@ -0,0 +1,91 @@
+package dev //nolint:testpackage
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nhost/cli/clienv"
+)
+
+func TestSnapshotPath(t *testing.T) {
+	t.Parallel()
+
+	ce := clienv.New(
+		io.Discard,
+		io.Discard,
+		clienv.NewPathStructure(".", ".", ".nhost", "nhost"),
+		"",
+		"",
+		"feature/login",
+		"test",
+		"local",
+	)
+
+	cases := []struct {
+		name     string
+		snapshot string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "valid",
+			snapshot: "before-migration_1.2",
+			expected: filepath.Join(ce.Path.Snapshots("feature/login"), "before-migration_1.2.dump"),
+			err:      false,
+		},
+		{
+			name:     "path traversal",
+			snapshot: "../../etc/passwd",
+			expected: "",
+			err:      true,
+		},
+		{
+			name:     "spaces",
+			snapshot: "my snapshot",
+			expected: "",
+			err:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := snapshotPath(ce, tc.snapshot)
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		size     int64
+		expected string
+	}{
+		{name: "bytes", size: 512, expected: "512 B"},
+		{name: "kibibytes", size: 1536, expected: "1.5 KiB"},
+		{name: "mebibytes", size: 10 * 1024 * 1024, expected: "10.0 MiB"},
+		{name: "gibibytes", size: 3 * 1024 * 1024 * 1024, expected: "3.0 GiB"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, humanSize(tc.size)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
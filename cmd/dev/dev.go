@@ -9,7 +9,16 @@ func Command() *cli.Command {
 		Usage:   "Operate local development environment",
 		Subcommands: []*cli.Command{
 			CommandCompose(),
+			CommandDB(),
+			CommandExec(),
+			CommandExport(),
 			CommandHasura(),
+			CommandImages(),
+			CommandMail(),
+			CommandMigrate(),
+			CommandPsql(),
+			CommandSMS(),
+			CommandVolumes(),
 		},
 	}
 }
@@ -29,11 +29,20 @@ func CommandDown() *cli.Command {
 func commandDown(cCtx *cli.Context) error {
 	ce := clienv.FromCLI(cCtx)
 
-	dc := dockercompose.New(ce.Path.WorkingDir(), ce.Path.DockerCompose(), ce.ProjectName())
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
 
 	if err := dc.Stop(cCtx.Context, cCtx.Bool(flagVolumes)); err != nil {
 		ce.Warnln("failed to stop Nhost development environment: %s", err)
 	}
 
+	if err := newSharedIngress(ce).Unregister(cCtx.Context, ce.ProjectName()); err != nil {
+		ce.Warnln("failed to leave the shared ingress: %s", err)
+	}
+
 	return nil
 }
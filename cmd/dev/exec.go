@@ -0,0 +1,105 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+func CommandExec() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:         "exec",
+		Aliases:      []string{},
+		Usage:        "Run a command in a service of the local development environment",
+		ArgsUsage:    "<service> -- <command> [args...]",
+		Action:       commandExec,
+		BashComplete: completeServices,
+	}
+}
+
+// runningComposeFile returns the docker compose project and the compose file of
+// the running environment.
+func runningComposeFile(
+	ce *clienv.CliEnv,
+) (*dockercompose.DockerCompose, *dockercompose.ComposeFile, error) {
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
+
+	composeFile, err := dc.ReadComposeFile()
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	if composeFile == nil {
+		return nil, nil, errors.New( //nolint:err113
+			"no development environment found, start it with `nhost up`",
+		)
+	}
+
+	return dc, composeFile, nil
+}
+
+func serviceNames(composeFile *dockercompose.ComposeFile) []string {
+	names := make([]string, 0, len(composeFile.Services))
+	for name := range composeFile.Services {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// completeServices completes the first argument with the services of the
+// environment.
+func completeServices(cCtx *cli.Context) {
+	if cCtx.NArg() > 0 {
+		return
+	}
+
+	_, composeFile, err := runningComposeFile(clienv.FromCLI(cCtx))
+	if err != nil {
+		return
+	}
+
+	for _, name := range serviceNames(composeFile) {
+		fmt.Fprintln(cCtx.App.Writer, name)
+	}
+}
+
+func commandExec(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	args := cCtx.Args().Slice()
+	if len(args) > 1 && args[1] == "--" {
+		args = slices.Delete(args, 1, 2)
+	}
+
+	if len(args) < 2 { //nolint:mnd
+		return errors.New("a service and a command are required") //nolint:err113
+	}
+
+	dc, composeFile, err := runningComposeFile(ce)
+	if err != nil {
+		return err
+	}
+
+	service := args[0]
+	if _, ok := composeFile.Services[service]; !ok {
+		return fmt.Errorf( //nolint:err113
+			"service %s not found, available services: %s",
+			service, strings.Join(serviceNames(composeFile), ", "),
+		)
+	}
+
+	return dc.Wrapper(cCtx.Context, append([]string{"exec", service}, args[1:]...)...) //nolint:wrapcheck
+}
@@ -0,0 +1,161 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagFormat    = "format"
+	flagNamespace = "namespace"
+	flagSecrets   = "secrets"
+)
+
+const (
+	formatK8s     = "k8s"
+	formatCompose = "compose"
+)
+
+func CommandExport() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "export",
+		Aliases: []string{},
+		Usage:   "Export the local development environment to run it elsewhere",
+		Action:  commandExport,
+		Flags: append(
+			imagesFlags(),
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagFormat,
+				Usage: "Format to export to: " + formatK8s + " or " + formatCompose + ", a compose project that runs with `docker compose up`, without the CLI, i.e. in CI", //nolint:lll
+				Value: formatK8s,
+				Action: func(_ *cli.Context, format string) error {
+					if format != formatK8s && format != formatCompose {
+						return fmt.Errorf("unsupported format %s", format) //nolint:err113
+					}
+
+					return nil
+				},
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:    flagOutput,
+				Aliases: []string{"o"},
+				Usage:   "Path to write the export to, stdout if not set. For compose it is the folder to write the project to, it must be inside the project", //nolint:lll
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagSecrets,
+				Usage: "Secrets file to resolve the configuration with, defaults to the project's .secrets",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagNamespace,
+				Usage: "Namespace of the kubernetes objects, the current one of kubectl if not set",
+			},
+			&cli.BoolFlag{ //nolint:exhaustruct
+				Name:  flagApplySeeds,
+				Usage: "Apply seeds after migrations and metadata, compose only",
+				Value: false,
+			},
+		),
+	}
+}
+
+func commandExport(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	secretsPath := cCtx.String(flagSecrets)
+	if secretsPath == "" {
+		secretsPath = ce.Path.Secrets()
+	}
+
+	// the bundle runs as its own project next to the environment of `nhost up`
+	projectName := ce.ProjectName()
+	if cCtx.String(flagFormat) == formatCompose {
+		projectName = dockercompose.BundleProjectName(projectName)
+	}
+
+	composeFile, err := projectComposeFile(cCtx, ce, projectName, secretsPath)
+	if err != nil {
+		return err
+	}
+
+	if cCtx.String(flagFormat) == formatCompose {
+		return exportCompose(cCtx, ce, projectName, composeFile)
+	}
+
+	return exportKubernetes(cCtx, ce, composeFile)
+}
+
+func exportKubernetes(
+	cCtx *cli.Context, ce *clienv.CliEnv, composeFile *dockercompose.ComposeFile,
+) error {
+	manifests, err := composeFile.Kubernetes(cCtx.String(flagNamespace))
+	if err != nil {
+		return fmt.Errorf("failed to convert to kubernetes manifests: %w", err)
+	}
+
+	b, err := manifests.YAML()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// warnings are also at the top of the manifests
+	output := cCtx.String(flagOutput)
+	if output == "" {
+		_, err := os.Stdout.Write(b)
+		return err //nolint:wrapcheck
+	}
+
+	for _, warning := range manifests.Warnings {
+		ce.Warnln("%s", warning)
+	}
+
+	if err := os.WriteFile(output, b, 0o600); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	ce.Infoln("Kubernetes manifests written to %s", output)
+
+	return nil
+}
+
+func exportCompose(
+	cCtx *cli.Context,
+	ce *clienv.CliEnv,
+	projectName string,
+	composeFile *dockercompose.ComposeFile,
+) error {
+	output := cCtx.String(flagOutput)
+	if output == "" {
+		return fmt.Errorf( //nolint:err113
+			"--%s is required to export to %s", flagOutput, formatCompose,
+		)
+	}
+
+	if err := dockercompose.WriteBundle(
+		composeFile,
+		output,
+		projectName,
+		ce.Path.Root(),
+		ce.Path.NhostFolder(),
+		ce.Path.DotNhostFolder(),
+		cCtx.Bool(flagApplySeeds),
+	); err != nil {
+		return fmt.Errorf("failed to write compose project: %w", err)
+	}
+
+	ce.Infoln("Compose project written to %s", output)
+	ce.Infoln(
+		"Start it with `docker compose up -d` from %s, `docker compose wait bootstrap` waits for migrations and metadata", //nolint:lll
+		output,
+	)
+	ce.Warnln(
+		"%s contains the resolved secrets, don't commit it unless they are meant for CI",
+		filepath.Join(output, "docker-compose.yaml"),
+	)
+
+	return nil
+}
@@ -29,7 +29,7 @@ func commandHasura(cCtx *cli.Context) error {
 		return fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	docker := dockercompose.NewDocker()
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
 
 	return docker.HasuraWrapper( //nolint:wrapcheck
 		cCtx.Context,
@@ -0,0 +1,249 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagInput = "input"
+	flagPull  = "pull"
+)
+
+// imagesFlags are the flags of `nhost up` that change which services and
+// images are used.
+func imagesFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{ //nolint:exhaustruct
+			Name:    flagDashboardVersion,
+			Usage:   "Dashboard version to use",
+			Value:   defaultDashboardVersion,
+			EnvVars: []string{"NHOST_DASHBOARD_VERSION"},
+		},
+		&cli.StringFlag{ //nolint:exhaustruct
+			Name:    flagConfigserverImage,
+			Hidden:  true,
+			Value:   "",
+			EnvVars: []string{"NHOST_CONFIGSERVER_IMAGE"},
+		},
+		&cli.StringSliceFlag{ //nolint:exhaustruct
+			Name:    flagRunService,
+			Usage:   "Run service to include. Can be passed multiple times. Comma-separated values are also accepted. Format: /path/to/run-service.toml[:overlay_name]", //nolint:lll
+			EnvVars: []string{"NHOST_RUN_SERVICE"},
+		},
+		&cli.StringFlag{ //nolint:exhaustruct
+			Name:    flagProfile,
+			Usage:   "Services to include: minimal, backend or full",
+			Value:   dockercompose.ProfileFull,
+			EnvVars: []string{"NHOST_PROFILE"},
+		},
+		&cli.BoolFlag{ //nolint:exhaustruct
+			Name:    flagObservability,
+			Usage:   "Include grafana, prometheus and postgres-exporter",
+			Value:   false,
+			EnvVars: []string{"NHOST_OBSERVABILITY"},
+		},
+		&cli.StringSliceFlag{ //nolint:exhaustruct
+			Name:    flagWithout,
+			Usage:   "Services to leave out on top of the profile's. Valid values: " + strings.Join(dockercompose.OptionalServices(), ", "), //nolint:lll
+			EnvVars: []string{"NHOST_WITHOUT"},
+		},
+	}
+}
+
+func CommandImages() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "images",
+		Aliases: []string{},
+		Usage:   "Manage the images used by the local development environment, i.e. to run it without registry access", //nolint:lll
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List the images the local development environment uses",
+				Action: commandImagesList,
+				Flags:  imagesFlags(),
+			},
+			{
+				Name:   "pull",
+				Usage:  "Pull the images the local development environment uses",
+				Action: commandImagesPull,
+				Flags:  imagesFlags(),
+			},
+			{
+				Name:   "save",
+				Usage:  "Save the images the local development environment uses to a tar archive",
+				Action: commandImagesSave,
+				Flags: append(
+					imagesFlags(),
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagOutput,
+						Aliases:  []string{"o"},
+						Usage:    "Path of the tar archive to write",
+						Required: true,
+					},
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:  flagPull,
+						Usage: "Pull the images that aren't available locally before saving them",
+						Value: false,
+					},
+				),
+			},
+			{
+				Name:   "load",
+				Usage:  "Load images from a tar archive written by `nhost dev images save`",
+				Action: commandImagesLoad,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagInput,
+						Aliases:  []string{"i"},
+						Usage:    "Path of the tar archive to read",
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// projectComposeFile returns the compose file of the environment `nhost up`
+// would start with the secrets at secretsPath as the compose project projectName.
+func projectComposeFile(
+	cCtx *cli.Context, ce *clienv.CliEnv, projectName, secretsPath string,
+) (*dockercompose.ComposeFile, error) {
+	without, err := dockercompose.ServicesWithout(
+		cCtx.String(flagProfile), cCtx.StringSlice(flagWithout), cCtx.Bool(flagObservability),
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	cfg, runServicesCfg, err := loadProject(
+		ce, secretsPath, cCtx.StringSlice(flagRunService), nil, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	composeFile, err := dockercompose.ComposeFileFromConfig(
+		cfg,
+		ce.LocalSubdomain(),
+		projectName,
+		defaultHTTPPort,
+		true,
+		defaultPostgresPort,
+		ce.Path.NhostFolder(),
+		ce.Path.DotNhostFolder(),
+		ce.Path.Root(),
+		dockercompose.ExposePorts{}, //nolint:exhaustruct
+		ce.Branch(),
+		cCtx.String(flagDashboardVersion),
+		configserverImage(cCtx),
+		clienv.PathExists(ce.Path.Functions()),
+		"",
+		without,
+		nil,
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		runServicesCfg...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate docker-compose.yaml: %w", err)
+	}
+
+	return composeFile, nil
+}
+
+// projectImages returns the images the environment `nhost up` would start uses.
+func projectImages(cCtx *cli.Context, ce *clienv.CliEnv) ([]string, error) {
+	composeFile, err := projectComposeFile(cCtx, ce, ce.ProjectName(), ce.Path.Secrets())
+	if err != nil {
+		return nil, err
+	}
+
+	return composeFile.Images(), nil
+}
+
+func commandImagesList(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	images, err := projectImages(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		ce.Println("%s", image)
+	}
+
+	return nil
+}
+
+func commandImagesPull(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	images, err := projectImages(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	return docker.PullImages(cCtx.Context, os.Stdout, os.Stderr, images) //nolint:wrapcheck
+}
+
+func missingImagesErr(missing []string) error {
+	return fmt.Errorf( //nolint:err113
+		"the following images aren't available locally, pull them with `nhost dev images pull` or load them with `nhost dev images load`:\n  %s", //nolint:lll
+		strings.Join(missing, "\n  "),
+	)
+}
+
+func commandImagesSave(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	images, err := projectImages(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	missing, err := docker.MissingImages(cCtx.Context, images)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if len(missing) > 0 {
+		if !cCtx.Bool(flagPull) {
+			return missingImagesErr(missing)
+		}
+
+		if err := docker.PullImages(cCtx.Context, os.Stdout, os.Stderr, missing); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	ce.Infoln("Saving %d images to %s...", len(images), cCtx.String(flagOutput))
+
+	return docker.SaveImages( //nolint:wrapcheck
+		cCtx.Context, os.Stdout, os.Stderr, cCtx.String(flagOutput), images,
+	)
+}
+
+func commandImagesLoad(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	path := cCtx.String(flagInput)
+	if !clienv.PathExists(path) {
+		return errors.New("archive not found: " + path) //nolint:err113
+	}
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	return docker.LoadImages(cCtx.Context, os.Stdout, os.Stderr, path) //nolint:wrapcheck
+}
@@ -1,29 +1,258 @@
 package dev
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
 	"github.com/nhost/cli/clienv"
 	"github.com/nhost/cli/dockercompose"
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	flagService = "service"
+	flagLevel   = "level"
+	flagSince   = "since"
+	flagUntil   = "until"
+	flagMatch   = "match"
+	flagRegex   = "regex"
+	flagFollow  = "follow"
+	flagTail    = "tail"
+	flagOutput  = "output"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const maxLogLineSize = 1024 * 1024
+
 func CommandLogs() *cli.Command {
 	return &cli.Command{ //nolint:exhaustruct
-		Name:            "logs",
-		Aliases:         []string{},
-		Usage:           "Show logs from local development environment",
-		Action:          commandLogs,
-		Flags:           []cli.Flag{},
-		SkipFlagParsing: true,
+		Name:      "logs",
+		Aliases:   []string{},
+		Usage:     "Show logs from local development environment",
+		ArgsUsage: "[service...]",
+		Action:    commandLogs,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{ //nolint:exhaustruct
+				Name:  flagService,
+				Usage: "Only show logs from these services. Can be passed multiple times. Services can also be passed as arguments", //nolint:lll
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagLevel,
+				Usage: "Only show logs with this level or above (trace, debug, info, warn, error, fatal)",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagSince,
+				Usage: "Show logs since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagUntil,
+				Usage: "Show logs before a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)", //nolint:lll
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagMatch,
+				Usage: "Only show log lines containing this text",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagRegex,
+				Usage: "Only show log lines matching this regular expression",
+			},
+			&cli.BoolFlag{ //nolint:exhaustruct
+				Name:    flagFollow,
+				Aliases: []string{"f"},
+				Usage:   "Follow log output",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagTail,
+				Usage: "Number of lines to show from the end of the logs for each service",
+				Value: "all",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:    flagOutput,
+				Aliases: []string{"o"},
+				Usage:   "Output format (text, json)",
+				Value:   outputText,
+			},
+		},
 	}
 }
 
+type logFilter struct {
+	level string
+	match string
+	regex *regexp.Regexp
+}
+
+func (f logFilter) matches(line string, entry dockercompose.LogEntry) bool {
+	if !dockercompose.LogLevelAtLeast(entry.Level, f.level) {
+		return false
+	}
+
+	if f.match != "" && !strings.Contains(line, f.match) {
+		return false
+	}
+
+	if f.regex != nil && !f.regex.MatchString(line) {
+		return false
+	}
+
+	return true
+}
+
+//nolint:gochecknoglobals
+var (
+	logTimestampStyle = lipgloss.NewStyle().Foreground(clienv.ANSIColorGray)
+	logServiceStyle   = lipgloss.NewStyle().Foreground(clienv.ANSIColorPurple)
+	logLevelStyles    = map[string]lipgloss.Style{
+		dockercompose.LogLevelTrace: lipgloss.NewStyle().Foreground(clienv.ANSIColorGray),
+		dockercompose.LogLevelDebug: lipgloss.NewStyle().Foreground(clienv.ANSIColorGray),
+		dockercompose.LogLevelInfo:  lipgloss.NewStyle().Foreground(clienv.ANSIColorCyan),
+		dockercompose.LogLevelWarn:  lipgloss.NewStyle().Foreground(clienv.ANSIColorYellow),
+		dockercompose.LogLevelError: lipgloss.NewStyle().Foreground(clienv.ANSIColorRed),
+		dockercompose.LogLevelFatal: lipgloss.NewStyle().Foreground(clienv.ANSIColorRed).Bold(true),
+	}
+)
+
+func printLogEntry(ce *clienv.CliEnv, entry dockercompose.LogEntry, output string) error {
+	if output == outputJSON {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal log entry: %w", err)
+		}
+
+		ce.Println("%s", b)
+
+		return nil
+	}
+
+	level := entry.Level
+	if style, ok := logLevelStyles[level]; ok {
+		level = style.Render(fmt.Sprintf("%-5s", strings.ToUpper(level)))
+	} else {
+		level = fmt.Sprintf("%-5s", "")
+	}
+
+	timestamp := ""
+	if !entry.Timestamp.IsZero() {
+		timestamp = logTimestampStyle.Render(entry.Timestamp.Local().Format(time.RFC3339))
+	}
+
+	ce.Println(
+		"%s %s %s %s",
+		timestamp,
+		logServiceStyle.Render(fmt.Sprintf("%-12s", entry.Service)),
+		level,
+		entry.Message,
+	)
+
+	return nil
+}
+
+func showLogs(
+	ctx context.Context,
+	ce *clienv.CliEnv,
+	dc *dockercompose.DockerCompose,
+	filter logFilter,
+	output string,
+	opts dockercompose.LogsOptions,
+	services []string,
+) error {
+	r, w := io.Pipe()
+
+	errCh := make(chan error, 1)
+	go func() {
+		err := dc.Logs(ctx, w, opts, services...)
+		w.CloseWithError(err)
+		errCh <- err
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLineSize)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		entry := dockercompose.ParseLogLine(line, ce.ProjectName())
+		if !filter.matches(line, entry) {
+			continue
+		}
+
+		if err := printLogEntry(ce, entry, output); err != nil {
+			r.CloseWithError(err)
+			return err
+		}
+	}
+
+	// unblock the producer, otherwise it waits forever for the line to be read
+	if err := scanner.Err(); err != nil {
+		r.CloseWithError(err)
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	if err := <-errCh; err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
+
 func commandLogs(cCtx *cli.Context) error {
 	ce := clienv.FromCLI(cCtx)
 
-	dc := dockercompose.New(ce.Path.WorkingDir(), ce.Path.DockerCompose(), ce.ProjectName())
+	output := cCtx.String(flagOutput)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("unknown output format %q", output) //nolint:err113
+	}
+
+	level := strings.ToLower(cCtx.String(flagLevel))
+	if level != "" && !dockercompose.IsLogLevel(level) {
+		return fmt.Errorf("unknown log level %q", level) //nolint:err113
+	}
+
+	filter := logFilter{
+		level: level,
+		match: cCtx.String(flagMatch),
+		regex: nil,
+	}
+
+	if cCtx.String(flagRegex) != "" {
+		re, err := regexp.Compile(cCtx.String(flagRegex))
+		if err != nil {
+			return fmt.Errorf("failed to parse regex: %w", err)
+		}
+
+		filter.regex = re
+	}
+
+	opts := dockercompose.LogsOptions{
+		Follow:     cCtx.Bool(flagFollow),
+		Timestamps: true,
+		Tail:       cCtx.String(flagTail),
+		Since:      cCtx.String(flagSince),
+		Until:      cCtx.String(flagUntil),
+	}
+
+	services := append(cCtx.StringSlice(flagService), cCtx.Args().Slice()...)
+
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
 
-	if err := dc.Logs(cCtx.Context, cCtx.Args().Slice()...); err != nil {
-		ce.Warnln("%s", err)
+	if err := showLogs(cCtx.Context, ce, dc, filter, output, opts, services); err != nil {
+		return fmt.Errorf("failed to show logs: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,280 @@
+package dev
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/nhost/cli/mailhog"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagLimit   = "limit"
+	flagTimeout = "timeout"
+	flagSubject = "subject"
+	flagLinks   = "links"
+)
+
+const mailPollInterval = time.Second
+
+func CommandMail() *cli.Command {
+	jsonFlag := &cli.BoolFlag{ //nolint:exhaustruct
+		Name:  flagJSON,
+		Usage: "Output in JSON format",
+		Value: false,
+	}
+	linksFlag := &cli.BoolFlag{ //nolint:exhaustruct
+		Name:  flagLinks,
+		Usage: "Only print the links found in the message, one per line",
+		Value: false,
+	}
+
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "mail",
+		Aliases: []string{},
+		Usage:   "Inspect the emails sent in the local development environment",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List received emails, most recent first",
+				Action: commandMailList,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagTo,
+						Usage: "Only show emails sent to this address",
+					},
+					&cli.IntFlag{ //nolint:exhaustruct
+						Name:  flagLimit,
+						Usage: "Maximum number of emails to show",
+						Value: 50, //nolint:mnd
+					},
+					jsonFlag,
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show an email and the links it contains",
+				ArgsUsage: "<id>",
+				Action:    commandMailShow,
+				Flags:     []cli.Flag{jsonFlag, linksFlag},
+			},
+			{
+				Name:   "wait",
+				Usage:  "Wait for an email to arrive and show it. Useful to get sign in or verification links in tests", //nolint:lll
+				Action: commandMailWait,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagTo,
+						Usage:    "Wait for an email sent to this address",
+						Required: true,
+					},
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagSubject,
+						Usage: "Only consider emails whose subject contains this text",
+					},
+					&cli.DurationFlag{ //nolint:exhaustruct
+						Name:  flagSince,
+						Usage: "Also consider emails received this long before the command started",
+						Value: 30 * time.Second, //nolint:mnd
+					},
+					&cli.DurationFlag{ //nolint:exhaustruct
+						Name:  flagTimeout,
+						Usage: "How long to wait for the email",
+						Value: 30 * time.Second, //nolint:mnd
+					},
+					jsonFlag,
+					linksFlag,
+				},
+			},
+		},
+	}
+}
+
+func mailhogClient(ce *clienv.CliEnv) (*mailhog.Client, error) {
+	composeFile, err := readComposeFile(ce)
+	if err != nil {
+		return nil, err
+	}
+
+	httpPort, useTLS, err := composeFile.Entrypoint()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return mailhog.New(dockercompose.LocalURL(
+		composeFile.LocalDomain(), ce.LocalSubdomain(), "mailhog", httpPort, useTLS,
+	)), nil
+}
+
+func printMessage(ce *clienv.CliEnv, msg *mailhog.Message, asJSON, linksOnly bool) error {
+	switch {
+	case linksOnly:
+		for _, link := range msg.Links {
+			ce.Println("%s", link)
+		}
+	case asJSON:
+		b, err := json.MarshalIndent(msg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+
+		ce.Println("%s", b)
+	default:
+		ce.Println("ID:      %s", msg.ID)
+		ce.Println("From:    %s", msg.From)
+		ce.Println("To:      %s", strings.Join(msg.To, ", "))
+		ce.Println("Subject: %s", msg.Subject)
+		ce.Println("Date:    %s", msg.Created.Local().Format(time.RFC3339))
+
+		if msg.Text != "" {
+			ce.Println("\n%s", strings.TrimSpace(msg.Text))
+		}
+
+		if len(msg.Links) > 0 {
+			ce.Println("\nLinks:")
+
+			for _, link := range msg.Links {
+				ce.Println("- %s", link)
+			}
+		}
+	}
+
+	return nil
+}
+
+func commandMailList(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	client, err := mailhogClient(ce)
+	if err != nil {
+		return err
+	}
+
+	msgs, err := client.List(cCtx.Context, cCtx.String(flagTo), cCtx.Int(flagLimit))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if cCtx.Bool(flagJSON) {
+		b, err := json.MarshalIndent(msgs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal messages: %w", err)
+		}
+
+		ce.Println("%s", b)
+
+		return nil
+	}
+
+	id := clienv.Column{
+		Header: "ID",
+		Rows:   make([]string, 0),
+	}
+	date := clienv.Column{
+		Header: "Date",
+		Rows:   make([]string, 0),
+	}
+	to := clienv.Column{
+		Header: "To",
+		Rows:   make([]string, 0),
+	}
+	subject := clienv.Column{
+		Header: "Subject",
+		Rows:   make([]string, 0),
+	}
+
+	for _, msg := range msgs {
+		id.Rows = append(id.Rows, msg.ID)
+		date.Rows = append(date.Rows, msg.Created.Local().Format(time.RFC3339))
+		to.Rows = append(to.Rows, strings.Join(msg.To, ", "))
+		subject.Rows = append(subject.Rows, msg.Subject)
+	}
+
+	ce.Println("%s", clienv.Table(id, date, to, subject))
+
+	return nil
+}
+
+func commandMailShow(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	id := cCtx.Args().First()
+	if id == "" {
+		return errors.New("message id is required") //nolint:err113
+	}
+
+	client, err := mailhogClient(ce)
+	if err != nil {
+		return err
+	}
+
+	msg, err := client.Get(cCtx.Context, id)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return printMessage(ce, msg, cCtx.Bool(flagJSON), cCtx.Bool(flagLinks))
+}
+
+func waitMessage(
+	ctx context.Context,
+	client *mailhog.Client,
+	to string,
+	subject string,
+	since time.Time,
+) (*mailhog.Message, error) {
+	ticker := time.NewTicker(mailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		msgs, err := client.List(ctx, to, 50) //nolint:mnd
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		for _, msg := range msgs {
+			if msg.Created.Before(since) || !strings.Contains(msg.Subject, subject) {
+				continue
+			}
+
+			return msg, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no email received for %s: %w", to, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func commandMailWait(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	client, err := mailhogClient(ce)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cCtx.Context, cCtx.Duration(flagTimeout))
+	defer cancel()
+
+	msg, err := waitMessage(
+		ctx,
+		client,
+		cCtx.String(flagTo),
+		cCtx.String(flagSubject),
+		time.Now().Add(-cCtx.Duration(flagSince)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return printMessage(ce, msg, cCtx.Bool(flagJSON), cCtx.Bool(flagLinks))
+}
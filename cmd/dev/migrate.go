@@ -0,0 +1,263 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagDatabaseName = "database-name"
+	flagFromSQL      = "from-sql"
+	flagName         = "name"
+	flagDeleteSource = "delete-source"
+	flagVersion      = "version"
+)
+
+func migrateFlags(flags ...cli.Flag) []cli.Flag {
+	return append(
+		[]cli.Flag{
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagDatabaseName,
+				Usage: "Database the migrations belong to",
+				Value: "default",
+			},
+		},
+		flags...,
+	)
+}
+
+func CommandMigrate() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "migrate",
+		Aliases: []string{},
+		Usage:   "Manage the migrations of the local development environment",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "status",
+				Usage:  "List the migrations and whether they are applied",
+				Action: commandMigrateStatus,
+				Flags:  migrateFlags(),
+			},
+			{
+				Name:      "create",
+				Usage:     "Create a migration",
+				ArgsUsage: "<name>",
+				Action:    commandMigrateCreate,
+				Flags: migrateFlags(
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagFromSQL,
+						Usage: "SQL file to use as the up step of the migration, empty if not set",
+					},
+				),
+			},
+			{
+				Name:   "squash",
+				Usage:  "Squash the migrations from a version onwards into one",
+				Action: commandMigrateSquash,
+				Flags: migrateFlags(
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagFrom,
+						Usage:    "Version of the first migration to squash",
+						Required: true,
+					},
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagName,
+						Usage: "Name of the squashed migration",
+						Value: "squashed",
+					},
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:  flagDeleteSource,
+						Usage: "Delete the migrations that were squashed",
+						Value: false,
+					},
+				),
+			},
+			{
+				Name:   "down",
+				Usage:  "Roll back a migration",
+				Action: commandMigrateDown,
+				Flags: migrateFlags(
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagVersion,
+						Usage:    "Version of the migration to roll back",
+						Required: true,
+					},
+				),
+			},
+		},
+	}
+}
+
+// hasuraTarget returns the running environment's hasura with the admin secret
+// of the project's configuration.
+func hasuraTarget(
+	cCtx *cli.Context, ce *clienv.CliEnv,
+) (*dockercompose.Docker, dockercompose.HasuraTarget, error) {
+	cfg, _, err := loadProject(ce, ce.Path.Secrets(), nil, nil, false)
+	if err != nil {
+		return nil, dockercompose.HasuraTarget{}, err //nolint:exhaustruct
+	}
+
+	runtime := dockercompose.NewRuntime(ce.ContainerRuntime())
+
+	composeFile, err := dockercompose.New(
+		runtime, ce.Path.WorkingDir(), ce.Path.DockerCompose(), ce.ProjectName(),
+	).ReadComposeFile()
+	if err != nil {
+		return nil, dockercompose.HasuraTarget{}, err //nolint:exhaustruct,wrapcheck
+	}
+
+	if composeFile == nil {
+		return nil, dockercompose.HasuraTarget{}, errors.New( //nolint:exhaustruct,err113
+			"no development environment found, start it with `nhost up`",
+		)
+	}
+
+	port, useTLS, err := composeFile.Entrypoint()
+	if err != nil {
+		return nil, dockercompose.HasuraTarget{}, err //nolint:exhaustruct,wrapcheck
+	}
+
+	domain := composeFile.LocalDomain()
+	docker := dockercompose.NewDocker(runtime).WithLocalDomain(newLocalDomain(domain))
+
+	return docker, dockercompose.HasuraTarget{
+		Subdomain:   ce.LocalSubdomain(),
+		NhostFolder: ce.Path.NhostFolder(),
+		Version:     *cfg.Hasura.Version,
+		Endpoint:    dockercompose.LocalURL(domain, ce.LocalSubdomain(), "hasura", port, useTLS),
+		AdminSecret: cfg.Hasura.AdminSecret,
+		Database:    cCtx.String(flagDatabaseName),
+	}, nil
+}
+
+func commandMigrateStatus(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	docker, target, err := hasuraTarget(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := docker.MigrateStatus(cCtx.Context, target)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	present := func(b bool) string {
+		if b {
+			return "present"
+		}
+
+		return "missing"
+	}
+
+	pending := 0
+
+	version := clienv.Column{Header: "Version", Rows: make([]string, 0, len(migrations))}
+	name := clienv.Column{Header: "Name", Rows: make([]string, 0, len(migrations))}
+	source := clienv.Column{Header: "Source", Rows: make([]string, 0, len(migrations))}
+	database := clienv.Column{Header: "Database", Rows: make([]string, 0, len(migrations))}
+
+	for _, m := range migrations {
+		version.Rows = append(version.Rows, m.Version)
+		name.Rows = append(name.Rows, m.Name)
+		source.Rows = append(source.Rows, present(m.SourcePresent))
+		database.Rows = append(database.Rows, present(m.DatabasePresent))
+
+		if m.Pending() {
+			pending++
+		}
+	}
+
+	ce.Println("%s", clienv.Table(version, name, source, database))
+
+	if pending > 0 {
+		ce.Warnln("%d migrations pending, run `nhost up` to apply them", pending)
+	}
+
+	return nil
+}
+
+func commandMigrateCreate(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	if cCtx.NArg() != 1 {
+		return errors.New("the name of the migration is required") //nolint:err113
+	}
+
+	sqlFile := cCtx.String(flagFromSQL)
+	if sqlFile != "" {
+		if _, err := os.Stat(sqlFile); err != nil {
+			return fmt.Errorf("failed to read %s: %w", sqlFile, err)
+		}
+	}
+
+	docker, target, err := hasuraTarget(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	name := cCtx.Args().First()
+
+	version, err := docker.MigrateCreate(cCtx.Context, target, name, sqlFile)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	ce.Infoln(
+		"Created %s",
+		filepath.Join(target.NhostFolder, "migrations", target.Database, version+"_"+name),
+	)
+
+	return nil
+}
+
+func commandMigrateSquash(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	docker, target, err := hasuraTarget(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	name := cCtx.String(flagName)
+
+	version, err := docker.MigrateSquash(
+		cCtx.Context, target, cCtx.String(flagFrom), name, cCtx.Bool(flagDeleteSource),
+	)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	ce.Infoln(
+		"Squashed migrations into %s and marked it as applied",
+		filepath.Join(target.NhostFolder, "migrations", target.Database, version+"_"+name),
+	)
+
+	return nil
+}
+
+func commandMigrateDown(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	docker, target, err := hasuraTarget(cCtx, ce)
+	if err != nil {
+		return err
+	}
+
+	version := cCtx.String(flagVersion)
+	if err := docker.MigrateDown(cCtx.Context, os.Stdout, target, version); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	ce.Infoln("Rolled back migration %s", version)
+
+	return nil
+}
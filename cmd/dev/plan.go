@@ -0,0 +1,130 @@
+package dev
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+)
+
+// servicesToRestart are restarted after applying migrations and metadata so they
+// can reapply their own metadata.
+var servicesToRestart = []string{"storage", "auth", "ai", "functions"} //nolint:gochecknoglobals
+
+func printPlan(ce *clienv.CliEnv, plan *dockercompose.Plan) {
+	if !plan.HasChanges() {
+		ce.Infoln("No changes to services")
+		return
+	}
+
+	ce.Infoln("Plan:")
+
+	for _, name := range plan.Create {
+		ce.Println("  + %s (create)", name)
+	}
+
+	for _, name := range plan.Recreate {
+		ce.Println("  ~ %s (recreate)", name)
+	}
+
+	for _, name := range plan.Remove {
+		ce.Println("  - %s (remove)", name)
+	}
+
+	ce.Println("  %d service(s) unchanged", len(plan.Unchanged))
+}
+
+// restartServices returns the services in the compose file that need a restart
+// after reapplying migrations and metadata.
+func restartServices(composeFile *dockercompose.ComposeFile) []string {
+	services := make([]string, 0, len(servicesToRestart))
+	for _, name := range servicesToRestart {
+		if _, ok := composeFile.Services[name]; ok {
+			services = append(services, name)
+		}
+	}
+
+	return services
+}
+
+// hasuraChecksum returns a checksum of the migrations, metadata and seeds folders
+// and of the volume of the database they are applied to, each branch has its
+// own so switching branches starts from an empty database.
+func hasuraChecksum(ce *clienv.CliEnv) (string, error) {
+	state, err := snapshot(
+		filepath.Join(ce.Path.NhostFolder(), "migrations"),
+		filepath.Join(ce.Path.NhostFolder(), "metadata"),
+		filepath.Join(ce.Path.NhostFolder(), "seeds"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(state))
+	for path := range state {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "volume %s\n", dockercompose.PostgresVolume(ce.Branch()))
+
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s %d %d\n", path, state[path].modTime.UnixNano(), state[path].size)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hasuraUnchanged reports whether migrations, metadata and seeds are the same as
+// the last time they were applied.
+func hasuraUnchanged(ce *clienv.CliEnv) bool {
+	checksum, err := hasuraChecksum(ce)
+	if err != nil {
+		return false
+	}
+
+	return checksum == readHasuraChecksum(ce)
+}
+
+func readHasuraChecksum(ce *clienv.CliEnv) string {
+	b, err := os.ReadFile(ce.Path.HasuraChecksum())
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+// resetHasuraChecksum makes the next `nhost up` apply migrations and metadata,
+// i.e. after the database has been replaced.
+func resetHasuraChecksum(ce *clienv.CliEnv) error {
+	if err := os.Remove(ce.Path.HasuraChecksum()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove hasura checksum: %w", err)
+	}
+
+	return nil
+}
+
+func writeHasuraChecksum(ce *clienv.CliEnv) error {
+	checksum, err := hasuraChecksum(ce)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(
+		ce.Path.HasuraChecksum(), []byte(checksum), 0o644, //nolint:gosec,mnd
+	); err != nil {
+		return fmt.Errorf("failed to write hasura checksum: %w", err)
+	}
+
+	return nil
+}
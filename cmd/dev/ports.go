@@ -0,0 +1,247 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nhost/cli/dockercompose"
+)
+
+// portInUse reports whether something is already listening on the port.
+func portInUse(port uint, protocol string) bool {
+	addr := ":" + strconv.FormatUint(uint64(port), 10)
+
+	var err error
+	if protocol == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", addr); err == nil {
+			conn.Close()
+		}
+	} else {
+		var l net.Listener
+		if l, err = net.Listen("tcp", addr); err == nil {
+			l.Close()
+		}
+	}
+
+	return listenErrInUse(err, port, protocol)
+}
+
+// listenErrInUse interprets the error of listening on the port. Ports we aren't
+// allowed to bind, i.e. privileged ports on linux, are checked by connecting to
+// them instead as the container runtime binds them on our behalf. There is no
+// such check for udp so those are assumed to be free.
+func listenErrInUse(err error, port uint, protocol string) bool {
+	switch {
+	case errors.Is(err, syscall.EADDRINUSE):
+		return true
+	case protocol != "udp" && (errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM)):
+		conn, err := net.DialTimeout(
+			"tcp",
+			net.JoinHostPort("127.0.0.1", strconv.FormatUint(uint64(port), 10)),
+			portDialTimeout,
+		)
+		if err != nil {
+			return false
+		}
+
+		conn.Close()
+
+		return true
+	default:
+		return false
+	}
+}
+
+const portDialTimeout = 500 * time.Millisecond
+
+func freePort() (uint, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+
+	return uint(l.Addr().(*net.TCPAddr).Port), nil //nolint:forcetypeassert,gosec
+}
+
+// parseLsof parses the output of `lsof -F pc`, which prints the pid and the
+// command of each process prefixed by "p" and "c" respectively.
+func parseLsof(b []byte) string {
+	var pid, command string
+
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "p") && pid == "":
+			pid = line[1:]
+		case strings.HasPrefix(line, "c") && command == "":
+			command = line[1:]
+		}
+	}
+
+	if pid == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (pid %s)", command, pid)
+}
+
+//nolint:gochecknoglobals
+var ssProcessRe = regexp.MustCompile(`users:\(\("([^"]+)",pid=(\d+)`)
+
+// parseSS parses the output of `ss -p`, which lists the processes using the
+// socket as users:(("command",pid=123,fd=4)).
+func parseSS(b []byte) string {
+	match := ssProcessRe.FindSubmatch(b)
+	if match == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (pid %s)", match[1], match[2])
+}
+
+// portHolder describes the process listening on the port. It returns an empty
+// string if it can't be found, i.e. it belongs to another user or neither lsof
+// nor ss are installed.
+func portHolder(ctx context.Context, port uint, protocol string) string {
+	p := strconv.FormatUint(uint64(port), 10)
+
+	lsofArgs := []string{"-nP", "-iTCP:" + p, "-sTCP:LISTEN", "-Fpc"}
+	ssArgs := []string{"-ltnpH", "sport = :" + p}
+
+	if protocol == "udp" {
+		lsofArgs = []string{"-nP", "-iUDP:" + p, "-Fpc"}
+		ssArgs = []string{"-lunpH", "sport = :" + p}
+	}
+
+	if b, err := exec.CommandContext(ctx, "lsof", lsofArgs...).Output(); err == nil {
+		if holder := parseLsof(b); holder != "" {
+			return holder
+		}
+	}
+
+	if b, err := exec.CommandContext(ctx, "ss", ssArgs...).Output(); err == nil {
+		return parseSS(bytes.TrimSpace(b))
+	}
+
+	return ""
+}
+
+// errPortsUnavailable is returned before anything is started so there is
+// nothing to tear down.
+//
+//nolint:gochecknoglobals
+var errPortsUnavailable = errors.New("ports not available")
+
+// checkPorts fails if any port the environment publishes is taken by something
+// other than the environment itself.
+func checkPorts(
+	ctx context.Context,
+	dc *dockercompose.DockerCompose,
+	composeFile *dockercompose.ComposeFile,
+) error {
+	owned := make(map[uint]struct{})
+
+	previous, err := dc.ReadComposeFile()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if previous != nil {
+		containers, err := dc.Status(ctx)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		for _, c := range containers {
+			for _, p := range c.Publishers {
+				owned[p.PublishedPort] = struct{}{}
+			}
+		}
+	}
+
+	conflicts := make([]string, 0)
+
+	for _, p := range composeFile.PublishedPorts() {
+		if _, ok := owned[p.Port]; ok || !portInUse(p.Port, p.Protocol) {
+			continue
+		}
+
+		conflict := fmt.Sprintf("%d/%s, needed by %s, is in use", p.Port, p.Protocol, p.Service)
+		if holder := portHolder(ctx, p.Port, p.Protocol); holder != "" {
+			conflict += " by " + holder
+		}
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf(
+			"%w:\n  - %s\nstop the processes using them or choose other ports, `--http-port 0` and `--postgres-port 0` pick free ones", //nolint:lll
+			errPortsUnavailable,
+			strings.Join(conflicts, "\n  - "),
+		)
+	}
+
+	return nil
+}
+
+// resolvePort returns the port to use when 0 is requested, the one of the
+// existing environment if there is one so it doesn't move on every run or a
+// free one otherwise.
+func resolvePort(requested, previous uint) (uint, error) {
+	switch {
+	case requested != 0:
+		return requested, nil
+	case previous != 0:
+		return previous, nil
+	default:
+		return freePort()
+	}
+}
+
+// previousPorts returns the HTTP and postgres ports of the existing
+// environment if it is running or they are still free, 0 otherwise.
+func previousPorts(ctx context.Context, dc *dockercompose.DockerCompose) (uint, uint) {
+	previous, err := dc.ReadComposeFile()
+	if err != nil || previous == nil {
+		return 0, 0
+	}
+
+	running, err := dc.RunningServices(ctx)
+	if err != nil {
+		return 0, 0
+	}
+
+	usable := func(port uint) uint {
+		if len(running) > 0 || !portInUse(port, "tcp") {
+			return port
+		}
+
+		return 0
+	}
+
+	var httpPort, postgresPort uint
+
+	if port, _, err := previous.Entrypoint(); err == nil {
+		httpPort = usable(port)
+	}
+
+	for _, p := range previous.PublishedPorts() {
+		if p.Service == "postgres" {
+			postgresPort = usable(p.Port)
+		}
+	}
+
+	return httpPort, postgresPort
+}
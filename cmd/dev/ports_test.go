@@ -0,0 +1,90 @@
+package dev //nolint:testpackage
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePortHolder(t *testing.T) {
+	t.Parallel()
+
+	if diff := cmp.Diff(
+		"nginx (pid 1234)", parseLsof([]byte("p1234\ncnginx\nf6\n")),
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(
+		"postgres (pid 87)",
+		parseSS([]byte(`LISTEN 0 244 0.0.0.0:5432 0.0.0.0:* users:(("postgres",pid=87,fd=5))`)),
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if parseLsof(nil) != "" || parseSS([]byte("LISTEN 0 244 0.0.0.0:5432 0.0.0.0:*")) != "" {
+		t.Error("expected no holder when the process isn't listed")
+	}
+}
+
+func TestPortInUse(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := uint(l.Addr().(*net.TCPAddr).Port) //nolint:forcetypeassert,gosec
+
+	if !portInUse(port, "tcp") {
+		t.Errorf("expected port %d to be in use", port)
+	}
+
+	l.Close()
+
+	if portInUse(port, "tcp") {
+		t.Errorf("expected port %d to be free", port)
+	}
+
+	free, err := resolvePort(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if free == 0 || portInUse(free, "tcp") {
+		t.Errorf("expected a free port, got %d", free)
+	}
+
+	if got, _ := resolvePort(0, 8443); got != 8443 { //nolint:mnd
+		t.Errorf("expected the previous port to be reused, got %d", got)
+	}
+}
+
+func TestPortInUsePrivileged(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := uint(l.Addr().(*net.TCPAddr).Port) //nolint:forcetypeassert,gosec
+
+	// an unprivileged user gets EACCES listening on i.e. 443, even if it is busy
+	if !listenErrInUse(syscall.EACCES, port, "tcp") {
+		t.Errorf("expected privileged port %d to be in use", port)
+	}
+
+	l.Close()
+
+	if listenErrInUse(syscall.EACCES, port, "tcp") {
+		t.Errorf("expected privileged port %d to be free", port)
+	}
+
+	if listenErrInUse(syscall.EACCES, port, "udp") {
+		t.Error("expected privileged udp ports to be assumed free")
+	}
+}
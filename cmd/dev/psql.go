@@ -0,0 +1,74 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagRole = "role"
+	flagFile = "file"
+)
+
+//nolint:gochecknoglobals
+var psqlRoles = []string{"postgres", "nhost_hasura", "nhost_auth_admin"}
+
+func CommandPsql() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "psql",
+		Aliases: []string{},
+		Usage:   "Open psql in the database of the local development environment",
+		Action:  commandPsql,
+		Flags: []cli.Flag{
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:  flagRole,
+				Usage: "Role to connect as, one of " + strings.Join(psqlRoles, ", "),
+				Value: "postgres",
+			},
+			&cli.StringFlag{ //nolint:exhaustruct
+				Name:    flagFile,
+				Aliases: []string{"f"},
+				Usage:   "SQL file to run instead of opening an interactive session",
+			},
+		},
+	}
+}
+
+func commandPsql(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	role := cCtx.String(flagRole)
+	if !slices.Contains(psqlRoles, role) {
+		return fmt.Errorf( //nolint:err113
+			"invalid role %s, must be one of %s", role, strings.Join(psqlRoles, ", "),
+		)
+	}
+
+	dc, _, err := runningComposeFile(ce)
+	if err != nil {
+		return err
+	}
+
+	psql := []string{"psql", "-U", role, "-d", "local"}
+
+	path := cCtx.String(flagFile)
+	if path == "" {
+		return dc.Wrapper(cCtx.Context, append([]string{"exec", "postgres"}, psql...)...) //nolint:wrapcheck
+	}
+
+	// the file lives in the host so it is streamed to psql through stdin
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return dc.Exec( //nolint:wrapcheck
+		cCtx.Context, f, os.Stdout, "postgres", append(psql, "-v", "ON_ERROR_STOP=1")...,
+	)
+}
@@ -0,0 +1,70 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+)
+
+func newSharedIngress(ce *clienv.CliEnv) *dockercompose.SharedIngress {
+	return dockercompose.NewSharedIngress(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		filepath.Join(clienv.PathStateHome(), "ingress"),
+	)
+}
+
+// registerSharedIngress routes the environment through the shared ingress,
+// starting it if needed.
+func registerSharedIngress(
+	ctx context.Context,
+	ce *clienv.CliEnv,
+	dc *dockercompose.DockerCompose,
+	httpPort uint,
+) error {
+	previous, err := dc.ReadComposeFile()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if previous != nil {
+		if _, ok := previous.Services["traefik"]; ok {
+			running, err := dc.RunningServices(ctx)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			if len(running) > 0 {
+				return errors.New( //nolint:err113
+					"the environment is running with its own ingress, run `nhost down` before switching to the shared one", //nolint:lll
+				)
+			}
+		}
+	}
+
+	ingress := newSharedIngress(ce)
+
+	composeFile, err := ingress.ComposeFile(httpPort)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err := checkPorts(ctx, ingress.DockerCompose(), composeFile); err != nil {
+		return err
+	}
+
+	ce.Infoln("Routing %s through the shared ingress...", ce.LocalSubdomain())
+
+	return ingress.Register( //nolint:wrapcheck
+		ctx,
+		ce.LocalSubdomain(),
+		httpPort,
+		dockercompose.IngressRegistration{
+			Project:     ce.ProjectName(),
+			WorkingDir:  ce.Path.WorkingDir(),
+			ComposeFile: ce.Path.DockerCompose(),
+		},
+	)
+}
@@ -0,0 +1,225 @@
+package dev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/cmd/smscatcher"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const flagCode = "code"
+
+func CommandSMS() *cli.Command {
+	jsonFlag := &cli.BoolFlag{ //nolint:exhaustruct
+		Name:  flagJSON,
+		Usage: "Output in JSON format",
+		Value: false,
+	}
+
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "sms",
+		Aliases: []string{},
+		Usage:   "Inspect the SMS sent in the local development environment. Requires SMS passwordless sign in to be enabled", //nolint:lll
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List captured SMS, most recent first",
+				Action: commandSMSList,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagTo,
+						Usage: "Only show SMS sent to this phone number",
+					},
+					jsonFlag,
+				},
+			},
+			{
+				Name:   "wait",
+				Usage:  "Wait for an SMS to arrive and show it. Useful to get one time codes in tests",
+				Action: commandSMSWait,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagTo,
+						Usage:    "Wait for an SMS sent to this phone number",
+						Required: true,
+					},
+					&cli.DurationFlag{ //nolint:exhaustruct
+						Name:  flagSince,
+						Usage: "Also consider SMS received this long before the command started",
+						Value: 30 * time.Second, //nolint:mnd
+					},
+					&cli.DurationFlag{ //nolint:exhaustruct
+						Name:  flagTimeout,
+						Usage: "How long to wait for the SMS",
+						Value: 30 * time.Second, //nolint:mnd
+					},
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:  flagCode,
+						Usage: "Only print the code found in the SMS",
+						Value: false,
+					},
+					jsonFlag,
+				},
+			},
+		},
+	}
+}
+
+func listSMS(ctx context.Context, ce *clienv.CliEnv, to string) ([]smscatcher.Message, error) {
+	composeFile, err := readComposeFile(ce)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := composeFile.Services["sms"]; !ok {
+		return nil, fmt.Errorf( //nolint:err113
+			"sms catcher not running, enable SMS passwordless sign in and run `nhost up`",
+		)
+	}
+
+	httpPort, useTLS, err := composeFile.Entrypoint()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	u := dockercompose.LocalURL(
+		composeFile.LocalDomain(), ce.LocalSubdomain(), "dashboard", httpPort, useTLS,
+	) +
+		"/v1/sms/messages?" + url.Values{"to": {to}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second} //nolint:exhaustruct,mnd
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sms catcher: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf( //nolint:err113
+			"sms catcher returned status code (%d): %s", resp.StatusCode, string(b),
+		)
+	}
+
+	var msgs []smscatcher.Message
+	if err := json.Unmarshal(b, &msgs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return msgs, nil
+}
+
+func commandSMSList(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	msgs, err := listSMS(cCtx.Context, ce, cCtx.String(flagTo))
+	if err != nil {
+		return err
+	}
+
+	if cCtx.Bool(flagJSON) {
+		b, err := json.MarshalIndent(msgs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal messages: %w", err)
+		}
+
+		ce.Println("%s", b)
+
+		return nil
+	}
+
+	date := clienv.Column{
+		Header: "Date",
+		Rows:   make([]string, 0),
+	}
+	to := clienv.Column{
+		Header: "To",
+		Rows:   make([]string, 0),
+	}
+	code := clienv.Column{
+		Header: "Code",
+		Rows:   make([]string, 0),
+	}
+	body := clienv.Column{
+		Header: "Body",
+		Rows:   make([]string, 0),
+	}
+
+	for _, msg := range msgs {
+		date.Rows = append(date.Rows, msg.Created.Local().Format(time.RFC3339))
+		to.Rows = append(to.Rows, msg.To)
+		code.Rows = append(code.Rows, msg.Code)
+		body.Rows = append(body.Rows, msg.Body)
+	}
+
+	ce.Println("%s", clienv.Table(date, to, code, body))
+
+	return nil
+}
+
+func commandSMSWait(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	ctx, cancel := context.WithTimeout(cCtx.Context, cCtx.Duration(flagTimeout))
+	defer cancel()
+
+	to := cCtx.String(flagTo)
+	since := time.Now().Add(-cCtx.Duration(flagSince))
+
+	ticker := time.NewTicker(mailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		msgs, err := listSMS(ctx, ce, to)
+		if err != nil {
+			return err
+		}
+
+		if len(msgs) > 0 && !msgs[0].Created.Before(since) {
+			msg := msgs[0]
+
+			switch {
+			case cCtx.Bool(flagCode):
+				ce.Println("%s", msg.Code)
+			case cCtx.Bool(flagJSON):
+				b, err := json.MarshalIndent(msg, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal message: %w", err)
+				}
+
+				ce.Println("%s", b)
+			default:
+				ce.Println("To:   %s", msg.To)
+				ce.Println("Date: %s", msg.Created.Local().Format(time.RFC3339))
+				ce.Println("Code: %s", msg.Code)
+				ce.Println("\n%s", msg.Body)
+			}
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no sms received for %s: %w", to, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
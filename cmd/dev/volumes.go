@@ -0,0 +1,234 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagFrom = "from"
+	flagTo   = "to"
+)
+
+func CommandVolumes() *cli.Command {
+	return &cli.Command{ //nolint:exhaustruct
+		Name:    "volumes",
+		Aliases: []string{},
+		Usage:   "Manage the docker volumes of the local development environment. Volumes are created per git branch", //nolint:lll
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List volumes of all branches",
+				Action: commandVolumesList,
+			},
+			{
+				Name:   "copy",
+				Usage:  "Copy the volumes of a branch into another one, replacing its data",
+				Action: commandVolumesCopy,
+				Flags: []cli.Flag{
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:     flagFrom,
+						Usage:    "Branch to copy the volumes from",
+						Required: true,
+					},
+					&cli.StringFlag{ //nolint:exhaustruct
+						Name:  flagTo,
+						Usage: "Branch to copy the volumes to. Defaults to the current branch",
+					},
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:  flagForce,
+						Usage: "Overwrite volumes that already exist in the target branch",
+						Value: false,
+					},
+				},
+			},
+			{
+				Name:   "prune",
+				Usage:  "Delete volumes of branches that no longer exist in the git repository",
+				Action: commandVolumesPrune,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{ //nolint:exhaustruct
+						Name:    flagYes,
+						Usage:   "Skip confirmation",
+						EnvVars: []string{"NHOST_YES"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func printVolumes(ce *clienv.CliEnv, volumes []dockercompose.BranchVolume) {
+	name := clienv.Column{
+		Header: "Volume",
+		Rows:   make([]string, 0),
+	}
+	branch := clienv.Column{
+		Header: "Branch",
+		Rows:   make([]string, 0),
+	}
+	kind := clienv.Column{
+		Header: "Kind",
+		Rows:   make([]string, 0),
+	}
+
+	for _, v := range volumes {
+		name.Rows = append(name.Rows, v.Name)
+		branch.Rows = append(branch.Rows, v.Branch)
+		kind.Rows = append(kind.Rows, v.Kind)
+	}
+
+	ce.Println("%s", clienv.Table(name, branch, kind))
+}
+
+func commandVolumesList(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	volumes, err := docker.VolumeList(cCtx.Context, ce.ProjectName())
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	printVolumes(ce, volumes)
+
+	return nil
+}
+
+func commandVolumesCopy(cCtx *cli.Context) error { //nolint:cyclop
+	ce := clienv.FromCLI(cCtx)
+
+	from := cCtx.String(flagFrom)
+
+	to := cCtx.String(flagTo)
+	if to == "" {
+		to = ce.Branch()
+	}
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	volumes, err := docker.VolumeList(cCtx.Context, ce.ProjectName())
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	src := make([]dockercompose.BranchVolume, 0)
+	for _, v := range volumes {
+		if v, ok := v.InBranch(from); ok {
+			src = append(src, v)
+		}
+	}
+
+	if len(src) == 0 {
+		return fmt.Errorf("no volumes found for branch %s", from) //nolint:err113
+	}
+
+	if src[0].ForBranch(to) == src[0].Volume {
+		return errors.New("source and target branches are the same") //nolint:err113
+	}
+
+	dc := dockercompose.New(
+		dockercompose.NewRuntime(ce.ContainerRuntime()),
+		ce.Path.WorkingDir(),
+		ce.Path.DockerCompose(),
+		ce.ProjectName(),
+	)
+
+	running, err := dc.RunningServices(cCtx.Context)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	current := src[0].ForBranch(ce.Branch())
+	if len(running) > 0 && (current == src[0].Volume || current == src[0].ForBranch(to)) {
+		return errors.New( //nolint:err113
+			"the development environment is using these volumes, run `nhost down` first",
+		)
+	}
+
+	if !cCtx.Bool(flagForce) {
+		for _, v := range src {
+			dst := ce.ProjectName() + "_" + v.ForBranch(to)
+			if docker.VolumeExists(cCtx.Context, dst) {
+				return fmt.Errorf( //nolint:err113
+					"volume %s already exists, use --%s to overwrite it", dst, flagForce,
+				)
+			}
+		}
+	}
+
+	for _, v := range src {
+		dst := ce.ProjectName() + "_" + v.ForBranch(to)
+
+		ce.Infoln("Copying %s to %s...", v.Name, dst)
+
+		if err := docker.VolumeCopy(
+			cCtx.Context, ce.ProjectName(), v.Name, dst, v.ForBranch(to),
+		); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	ce.Infoln("Volumes of branch %s copied to branch %s", from, to)
+
+	return nil
+}
+
+func commandVolumesPrune(cCtx *cli.Context) error {
+	ce := clienv.FromCLI(cCtx)
+
+	branches, err := clienv.GitBranches()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// the current branch may be set with --branch and not exist in git
+	branches = append(branches, ce.Branch())
+
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
+
+	volumes, err := docker.VolumeList(cCtx.Context, ce.ProjectName())
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	stale := dockercompose.StaleVolumes(volumes, branches)
+	if len(stale) == 0 {
+		ce.Infoln("No stale volumes found")
+		return nil
+	}
+
+	printVolumes(ce, stale)
+
+	if !cCtx.Bool(flagYes) {
+		ce.PromptMessage("Delete these %d volumes? [y/N] ", len(stale))
+
+		resp, err := ce.PromptInput(false)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		if strings.ToLower(resp) != "y" {
+			return nil
+		}
+	}
+
+	names := make([]string, len(stale))
+	for i, v := range stale {
+		names[i] = v.Name
+	}
+
+	if err := docker.VolumeRemove(cCtx.Context, names...); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	ce.Infoln("Deleted %d volumes", len(stale))
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nhost/cli/clienv"
+	"github.com/nhost/cli/dockercompose"
+)
+
+const (
+	watchInterval = time.Second
+	watchDebounce = 500 * time.Millisecond
+)
+
+type watchAction uint8
+
+const (
+	watchActionMigrations watchAction = 1 << iota
+	watchActionMetadata
+	watchActionSeeds
+	watchActionConfig
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the state of every regular file found under paths. Paths that
+// don't exist are ignored so they can be created while watching.
+func snapshot(paths ...string) (map[string]fileState, error) {
+	state := make(map[string]fileState)
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+
+				return err //nolint:wrapcheck
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+
+				return err //nolint:wrapcheck
+			}
+
+			state[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", root, err)
+		}
+	}
+
+	return state, nil
+}
+
+func changedFiles(before, after map[string]fileState) []string {
+	changed := make([]string, 0)
+
+	for path, st := range after {
+		if prev, ok := before[path]; !ok || prev != st {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	return changed
+}
+
+func isUnder(path, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func classifyChanges(ce *clienv.CliEnv, changed []string) watchAction {
+	var action watchAction
+
+	for _, path := range changed {
+		switch {
+		case isUnder(path, filepath.Join(ce.Path.NhostFolder(), "migrations")):
+			action |= watchActionMigrations
+		case isUnder(path, filepath.Join(ce.Path.NhostFolder(), "metadata")):
+			action |= watchActionMetadata
+		case isUnder(path, filepath.Join(ce.Path.NhostFolder(), "seeds")):
+			action |= watchActionSeeds
+		default:
+			action |= watchActionConfig
+		}
+	}
+
+	return action
+}
+
+func watchedPaths(ce *clienv.CliEnv, runServices []string) []string {
+	paths := []string{
+		filepath.Join(ce.Path.NhostFolder(), "migrations"),
+		filepath.Join(ce.Path.NhostFolder(), "metadata"),
+		filepath.Join(ce.Path.NhostFolder(), "seeds"),
+		ce.Path.NhostToml(),
+		ce.Path.OverlaysFolder(),
+		ce.Path.Secrets(),
+	}
+
+	for _, runService := range runServices {
+		cfgPath, _, err := parseRunServiceConfigFlag(runService)
+		if err != nil {
+			continue
+		}
+
+		paths = append(paths, cfgPath, ce.Path.RunServiceOverlaysFolder(cfgPath))
+	}
+
+	return paths
+}
+
+func applyChanges(
+	ctx context.Context,
+	ce *clienv.CliEnv,
+	dc *dockercompose.DockerCompose,
+	action watchAction,
+	endpoint string,
+	regenerate func() (*dockercompose.ComposeFile, error),
+) error {
+	if action&watchActionConfig != 0 {
+		ce.Infoln("Configuration changed, updating services...")
+
+		composeFile, err := regenerate()
+		if err != nil {
+			return err
+		}
+
+		previous, err := dc.ReadComposeFile()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		printPlan(ce, dockercompose.NewPlan(previous, composeFile))
+
+		if err := dc.WriteComposeFile(composeFile); err != nil {
+			return fmt.Errorf("failed to write docker-compose.yaml: %w", err)
+		}
+
+		if err := dc.Start(ctx); err != nil {
+			return fmt.Errorf("failed to update Nhost development environment: %w", err)
+		}
+	}
+
+	if action&watchActionMigrations != 0 {
+		ce.Infoln("Migrations changed, applying migrations...")
+
+		if err := dc.ApplyMigrations(ctx, endpoint); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	}
+
+	if action&watchActionMetadata != 0 {
+		ce.Infoln("Metadata changed, applying metadata...")
+
+		if err := dc.ApplyMetadata(ctx, endpoint); err != nil {
+			return fmt.Errorf("failed to apply metadata: %w", err)
+		}
+
+		if err := dc.ReloadMetadata(ctx); err != nil {
+			return fmt.Errorf("failed to reload metadata: %w", err)
+		}
+	}
+
+	if action&watchActionSeeds != 0 {
+		ce.Infoln("Seeds changed, applying seeds...")
+
+		if err := dc.ApplySeeds(ctx, endpoint); err != nil {
+			return fmt.Errorf("failed to apply seeds: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// watch polls the project files and reapplies only the steps affected by each change
+// until ctx is cancelled. Errors are reported but don't stop the watcher.
+func watch(
+	ctx context.Context,
+	ce *clienv.CliEnv,
+	dc *dockercompose.DockerCompose,
+	paths []string,
+	endpoint string,
+	regenerate func() (*dockercompose.ComposeFile, error),
+) error {
+	current, err := snapshot(paths...)
+	if err != nil {
+		return err
+	}
+
+	ce.Infoln("Watching for changes, press Ctrl+C to stop...")
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := snapshot(paths...)
+		if err != nil {
+			ce.Warnln("%s", err)
+			continue
+		}
+
+		changed := changedFiles(current, next)
+		if len(changed) == 0 {
+			continue
+		}
+
+		// give editors and hasura-cli a moment to finish writing
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchDebounce):
+		}
+
+		next, err = snapshot(paths...)
+		if err != nil {
+			ce.Warnln("%s", err)
+			continue
+		}
+
+		changed = changedFiles(current, next)
+		current = next
+
+		if err := applyChanges(
+			ctx, ce, dc, classifyChanges(ce, changed), endpoint, regenerate,
+		); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			ce.Warnln("%s", err)
+
+			continue
+		}
+
+		// applying changes might have written files (i.e. metadata), ignore those
+		applied, err := snapshot(paths...)
+		if err != nil {
+			ce.Warnln("%s", err)
+			continue
+		}
+
+		current = applied
+
+		if err := writeHasuraChecksum(ce); err != nil {
+			ce.Warnln("%s", err)
+		}
+
+		ce.Infoln("Changes applied, watching for changes...")
+	}
+}
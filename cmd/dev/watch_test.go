@@ -0,0 +1,101 @@
+package dev //nolint:testpackage
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nhost/cli/clienv"
+)
+
+func TestClassifyChanges(t *testing.T) {
+	t.Parallel()
+
+	ce := clienv.New(
+		io.Discard,
+		io.Discard,
+		clienv.NewPathStructure(".", ".", ".nhost", "nhost"),
+		"",
+		"",
+		"main",
+		"test",
+		"local",
+	)
+
+	cases := []struct {
+		name     string
+		changed  []string
+		expected watchAction
+	}{
+		{
+			name:     "migrations",
+			changed:  []string{filepath.Join("nhost", "migrations", "default", "1_init", "up.sql")},
+			expected: watchActionMigrations,
+		},
+		{
+			name: "metadata and seeds",
+			changed: []string{
+				filepath.Join("nhost", "metadata", "version.yaml"),
+				filepath.Join("nhost", "seeds", "default", "001.sql"),
+			},
+			expected: watchActionMetadata | watchActionSeeds,
+		},
+		{
+			name: "config",
+			changed: []string{
+				filepath.Join("nhost", "nhost.toml"),
+				".secrets",
+				filepath.Join("nhost", "overlays", "local.json"),
+			},
+			expected: watchActionConfig,
+		},
+		{
+			name:     "folder with matching prefix",
+			changed:  []string{filepath.Join("nhost", "migrations-old", "up.sql")},
+			expected: watchActionConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := classifyChanges(ce, tc.changed); got != tc.expected {
+				t.Errorf("classifyChanges() = %b, want %b", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	before := map[string]fileState{
+		"unchanged": {modTime: now, size: 1},
+		"modified":  {modTime: now, size: 1},
+		"deleted":   {modTime: now, size: 1},
+	}
+	after := map[string]fileState{
+		"unchanged": {modTime: now, size: 1},
+		"modified":  {modTime: now.Add(time.Second), size: 1},
+		"created":   {modTime: now, size: 1},
+	}
+
+	got := make(map[string]struct{})
+	for _, path := range changedFiles(before, after) {
+		got[path] = struct{}{}
+	}
+
+	for _, path := range []string{"modified", "deleted", "created"} {
+		if _, ok := got[path]; !ok {
+			t.Errorf("expected %s to be reported as changed", path)
+		}
+	}
+
+	if len(got) != 3 { //nolint:mnd
+		t.Errorf("expected 3 changes, got %d", len(got))
+	}
+}
@@ -214,7 +214,7 @@ func deploy(
 	hasuraEndpoint string,
 	hasuraAdminSecret string,
 ) error {
-	docker := dockercompose.NewDocker()
+	docker := dockercompose.NewDocker(dockercompose.NewRuntime(ce.ContainerRuntime()))
 
 	ce.Infoln("Creating postgres migration")
 
@@ -0,0 +1,195 @@
+// Package smscatcher implements a fake twilio API that captures the SMS sent by
+// auth in the local development environment so they can be inspected with
+// `nhost dev sms`.
+package smscatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	bindFlag    = "bind"
+	bindTLSFlag = "bind-tls"
+	tlsCertFlag = "tls-cert"
+	tlsKeyFlag  = "tls-key"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{ //nolint: exhaustruct
+		Name:   "sms-catcher",
+		Usage:  "serve a fake twilio API that captures SMS",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{ //nolint: exhaustruct
+				Name:  bindFlag,
+				Usage: "bind address for plain HTTP",
+				Value: ":8089",
+			},
+			&cli.StringFlag{ //nolint: exhaustruct
+				Name:  bindTLSFlag,
+				Usage: "bind address for HTTPS, where twilio's API is served",
+				Value: ":443",
+			},
+			&cli.StringFlag{ //nolint: exhaustruct
+				Name:  tlsCertFlag,
+				Usage: "path to the TLS certificate for twilio's hosts",
+				Value: "/opt/sms/tls.crt",
+			},
+			&cli.StringFlag{ //nolint: exhaustruct
+				Name:  tlsKeyFlag,
+				Usage: "path to the TLS key for twilio's hosts",
+				Value: "/opt/sms/tls.key",
+			},
+		},
+		Action: serve,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type handler struct {
+	store  *store
+	logger *slog.Logger
+}
+
+// messages handles https://www.twilio.com/docs/messaging/api/message-resource#create-a-message-resource
+func (h *handler) messages(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+
+	from := r.PostForm.Get("From")
+	if from == "" {
+		from = r.PostForm.Get("MessagingServiceSid")
+	}
+
+	msg := h.store.add(r.PostForm.Get("To"), from, r.PostForm.Get("Body"), "")
+	h.logger.Info("captured sms", slog.String("to", msg.To), slog.String("body", msg.Body))
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"sid":                   msg.ID,
+		"account_sid":           r.PathValue("sid"),
+		"messaging_service_sid": r.PostForm.Get("MessagingServiceSid"),
+		"to":                    msg.To,
+		"from":                  r.PostForm.Get("From"),
+		"body":                  msg.Body,
+		"status":                "queued",
+		"direction":             "outbound-api",
+		"num_segments":          "1",
+		"api_version":           "2010-04-01",
+		"date_created":          msg.Created.Format(time.RFC1123Z),
+	})
+}
+
+// verifications handles https://www.twilio.com/docs/verify/api/verification#start-new-verification
+func (h *handler) verifications(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+
+	msg, err := h.store.startVerification(r.PathValue("sid"), r.PostForm.Get("To"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return
+	}
+
+	h.logger.Info("captured verification", slog.String("to", msg.To), slog.String("code", msg.Code))
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"sid":         newID("VE"),
+		"service_sid": r.PathValue("sid"),
+		"to":          msg.To,
+		"channel":     "sms",
+		"status":      "pending",
+		"valid":       false,
+	})
+}
+
+// verificationCheck handles https://www.twilio.com/docs/verify/api/verification-check
+func (h *handler) verificationCheck(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+
+	to := r.PostForm.Get("To")
+	valid := h.store.checkVerification(r.PathValue("sid"), to, r.PostForm.Get("Code"))
+
+	status := "pending"
+	if valid {
+		status = "approved"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sid":         newID("VE"),
+		"service_sid": r.PathValue("sid"),
+		"to":          to,
+		"channel":     "sms",
+		"status":      status,
+		"valid":       valid,
+	})
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.store.list(r.URL.Query().Get("to")))
+}
+
+func newMux(h *handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /2010-04-01/Accounts/{sid}/Messages.json", h.messages)
+	mux.HandleFunc("POST /v2/Services/{sid}/Verifications", h.verifications)
+	mux.HandleFunc("POST /v2/Services/{sid}/VerificationCheck", h.verificationCheck)
+	mux.HandleFunc("GET /v1/sms/messages", h.list)
+
+	return mux
+}
+
+func serve(cCtx *cli.Context) error {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger.Info(cCtx.App.Name + " v" + cCtx.App.Version)
+
+	mux := newMux(&handler{
+		store:  newStore(),
+		logger: logger,
+	})
+
+	errCh := make(chan error, 2) //nolint:mnd
+
+	go func() {
+		server := &http.Server{ //nolint:exhaustruct
+			Addr:              cCtx.String(bindFlag),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second, //nolint:mnd
+		}
+		errCh <- server.ListenAndServe()
+	}()
+
+	go func() {
+		server := &http.Server{ //nolint:exhaustruct
+			Addr:              cCtx.String(bindTLSFlag),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second, //nolint:mnd
+		}
+		errCh <- server.ListenAndServeTLS(cCtx.String(tlsCertFlag), cCtx.String(tlsKeyFlag))
+	}()
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+
+	return nil
+}
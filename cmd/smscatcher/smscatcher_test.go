@@ -0,0 +1,119 @@
+package smscatcher //nolint:testpackage
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, srv *httptest.Server, path string, form url.Values) map[string]any {
+	t.Helper()
+
+	resp, err := srv.Client().Post(
+		srv.URL+path, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func list(t *testing.T, srv *httptest.Server, to string) []Message {
+	t.Helper()
+
+	resp, err := srv.Client().Get(srv.URL + "/v1/sms/messages?" + url.Values{"to": {to}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var msgs []Message
+	if err := json.NewDecoder(resp.Body).Decode(&msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	return msgs
+}
+
+func TestMessages(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newMux(&handler{
+		store:  newStore(),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}))
+	defer srv.Close()
+
+	post(t, srv, "/2010-04-01/Accounts/AC123/Messages.json", url.Values{
+		"To":                  {"+15555550100"},
+		"MessagingServiceSid": {"MG123"},
+		"Body":                {"Your code is 123456"},
+	})
+	post(t, srv, "/2010-04-01/Accounts/AC123/Messages.json", url.Values{
+		"To":   {"+15555550199"},
+		"From": {"+15555550000"},
+		"Body": {"Your code is 654321"},
+	})
+
+	msgs := list(t, srv, "+15555550100")
+	if len(msgs) != 1 || msgs[0].Code != "123456" || msgs[0].From != "MG123" {
+		t.Errorf("unexpected messages: %+v", msgs)
+	}
+
+	if msgs := list(t, srv, ""); len(msgs) != 2 || msgs[0].To != "+15555550199" {
+		t.Errorf("expected all messages, most recent first, got: %+v", msgs)
+	}
+}
+
+func TestVerifications(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newMux(&handler{
+		store:  newStore(),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}))
+	defer srv.Close()
+
+	to := "+15555550100"
+
+	post(t, srv, "/v2/Services/VA123/Verifications", url.Values{"To": {to}, "Channel": {"sms"}})
+
+	msgs := list(t, srv, to)
+	if len(msgs) != 1 || len(msgs[0].Code) != 6 {
+		t.Fatalf("expected a verification code, got: %+v", msgs)
+	}
+
+	check := post(t, srv, "/v2/Services/VA123/VerificationCheck", url.Values{
+		"To": {to}, "Code": {"wrong"},
+	})
+	if check["status"] != "pending" {
+		t.Errorf("expected wrong code to be rejected, got: %v", check)
+	}
+
+	check = post(t, srv, "/v2/Services/VA123/VerificationCheck", url.Values{
+		"To": {to}, "Code": {msgs[0].Code},
+	})
+	if check["status"] != "approved" || check["valid"] != true {
+		t.Errorf("expected code to be approved, got: %v", check)
+	}
+
+	if resp, err := http.Get(srv.URL + "/unknown"); err == nil { //nolint:noctx
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package smscatcher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Message is an SMS captured by the catcher.
+type Message struct {
+	ID      string    `json:"id"`
+	To      string    `json:"to"`
+	From    string    `json:"from"`
+	Body    string    `json:"body"`
+	Code    string    `json:"code,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+var codeRe = regexp.MustCompile(`\b\d{4,8}\b`) //nolint:gochecknoglobals
+
+const maxMessages = 1000
+
+type store struct {
+	mu       sync.Mutex
+	messages []Message
+	// pending verification codes by service and phone number when using twilio verify
+	verifications map[string]string
+}
+
+func newStore() *store {
+	return &store{
+		mu:            sync.Mutex{},
+		messages:      make([]Message, 0),
+		verifications: make(map[string]string),
+	}
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 16) //nolint:mnd
+	_, _ = rand.Read(b)
+
+	return prefix + hex.EncodeToString(b)
+}
+
+func (s *store) add(to, from, body, code string) Message {
+	if code == "" {
+		code = codeRe.FindString(body)
+	}
+
+	msg := Message{
+		ID:      newID("SM"),
+		To:      to,
+		From:    from,
+		Body:    body,
+		Code:    code,
+		Created: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, msg)
+	if len(s.messages) > maxMessages {
+		s.messages = s.messages[len(s.messages)-maxMessages:]
+	}
+
+	return msg
+}
+
+// list returns the messages sent to "to", or all of them if empty, most recent first.
+func (s *store) list(to string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := make([]Message, 0, len(s.messages))
+	for _, msg := range slices.Backward(s.messages) {
+		if to == "" || msg.To == to {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+func (s *store) startVerification(service, to string) (Message, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000)) //nolint:mnd
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	s.mu.Lock()
+	s.verifications[service+"/"+to] = code
+	s.mu.Unlock()
+
+	return s.add(to, service, "Your verification code is: "+code, code), nil
+}
+
+func (s *store) checkVerification(service, to, code string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := service + "/" + to
+	if expected, ok := s.verifications[key]; ok && expected == code {
+		delete(s.verifications, key)
+		return true
+	}
+
+	return false
+}
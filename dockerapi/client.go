@@ -0,0 +1,180 @@
+// Package dockerapi is a small client for the parts of the Docker Engine API we
+// need to manage the development environment. Podman's API is compatible so it
+// works with both.
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var ErrUnsupportedHost = errors.New("unsupported docker host")
+
+// Error is returned when the API responds with an error status code.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("docker API returned status code (%d): %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	network string
+	address string
+	baseURL string
+	client  *http.Client
+}
+
+// New returns a client for the API at the given socket. Only unix sockets and
+// plain tcp are supported, hosts requiring TLS or ssh return ErrUnsupportedHost.
+func New(socket *url.URL) (*Client, error) {
+	var network, address, baseURL string
+
+	switch socket.Scheme {
+	case "unix":
+		network, address, baseURL = "unix", socket.Path, "http://docker"
+	case "tcp", "http":
+		if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			return nil, fmt.Errorf("%w: TLS is not supported", ErrUnsupportedHost)
+		}
+
+		network, address, baseURL = "tcp", socket.Host, "http://"+socket.Host
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHost, socket.String())
+	}
+
+	c := &Client{
+		network: network,
+		address: address,
+		baseURL: baseURL,
+		client:  nil,
+	}
+
+	c.client = &http.Client{ //nolint:exhaustruct
+		Transport: &http.Transport{ //nolint:exhaustruct
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return c.dial(ctx)
+			},
+		},
+	}
+
+	return c, nil
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (c *Client) newRequest(
+	ctx context.Context, method, path string, query url.Values, body any,
+) (*http.Request, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(b, &apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = string(b)
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Message: apiErr.Message}
+}
+
+// stream sends the request and returns the body of the response, which the
+// caller must close.
+func (c *Client) stream(
+	ctx context.Context, method, path string, query url.Values, body any,
+) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query docker: %w", err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// do sends the request and unmarshals the response into v if it isn't nil.
+func (c *Client) do(
+	ctx context.Context, method, path string, query url.Values, body any, v any,
+) error {
+	r, err := c.stream(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if v == nil {
+		_, _ = io.Copy(io.Discard, r)
+		return nil
+	}
+
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// Ping checks the API is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+}
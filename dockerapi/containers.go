@@ -0,0 +1,115 @@
+//nolint:tagliatelle
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type Port struct {
+	IP          string `json:"IP"`
+	PrivatePort uint   `json:"PrivatePort"`
+	PublicPort  uint   `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+type Container struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []Port            `json:"Ports"`
+}
+
+// Name returns the name of the container without the leading slash.
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+type Health struct {
+	Status string `json:"Status"`
+}
+
+type ContainerState struct {
+	Status   string  `json:"Status"`
+	Running  bool    `json:"Running"`
+	ExitCode int     `json:"ExitCode"`
+	Health   *Health `json:"Health"`
+}
+
+type ContainerConfig struct {
+	Tty bool `json:"Tty"`
+}
+
+type ContainerJSON struct {
+	ID     string          `json:"Id"`
+	Name   string          `json:"Name"`
+	State  ContainerState  `json:"State"`
+	Config ContainerConfig `json:"Config"`
+}
+
+// HealthStatus returns the status of the container's healthcheck or an empty
+// string if it doesn't have one.
+func (c *ContainerJSON) HealthStatus() string {
+	if c.State.Health == nil {
+		return ""
+	}
+
+	return c.State.Health.Status
+}
+
+// ContainerList returns the containers with all the given labels, in
+// "key=value" format. Stopped containers are included if all is true.
+func (c *Client) ContainerList(
+	ctx context.Context, all bool, labels ...string,
+) ([]Container, error) {
+	filters, err := json.Marshal(map[string][]string{"label": labels})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("filters", string(filters))
+
+	if all {
+		query.Set("all", "1")
+	}
+
+	var containers []Container
+	if err := c.do(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	return containers, nil
+}
+
+func (c *Client) ContainerInspect(ctx context.Context, id string) (*ContainerJSON, error) {
+	var container ContainerJSON
+	if err := c.do(
+		ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &container,
+	); err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", id, err)
+	}
+
+	return &container, nil
+}
+
+func (c *Client) ContainerRestart(ctx context.Context, id string) error {
+	if err := c.do(
+		ctx, http.MethodPost, "/containers/"+id+"/restart", nil, nil, nil,
+	); err != nil {
+		return fmt.Errorf("failed to restart container %s: %w", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package dockerapi //nolint:testpackage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, streamHeaderSize)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload))) //nolint:gosec
+
+	return append(header, payload...)
+}
+
+func TestDemux(t *testing.T) {
+	t.Parallel()
+
+	var in bytes.Buffer
+	in.Write(frame(streamStdout, "hello "))
+	in.Write(frame(streamStderr, "oops\n"))
+	in.Write(frame(streamStdout, "world\n"))
+
+	var stdout, stderr bytes.Buffer
+	if err := demux(&in, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff("hello world\n", stdout.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff("oops\n", stderr.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	if err := demux(bytes.NewReader(frame(streamStdout, "x")[:10]), io.Discard, io.Discard); err == nil {
+		t.Error("expected error on truncated frame")
+	}
+}
+
+func TestUnixTimestamp(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]string{
+		"10m":                  "1704109800",
+		"2024-01-01T11:00:00Z": "1704106800",
+		"2024-01-01":           "1704067200",
+		"1704067200.5":         "1704067200.5",
+	}
+	for value, expected := range cases {
+		got, err := unixTimestamp(value, now)
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+		}
+
+		if got != expected {
+			t.Errorf("%s: got %s, expected %s", value, got, expected)
+		}
+	}
+
+	if _, err := unixTimestamp("yesterday", now); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(&url.URL{Scheme: "tcp", Host: u.Host}) //nolint:exhaustruct
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// execHandler fakes the exec endpoints, the command uppercases its stdin and
+// exits with the given code.
+func execHandler(t *testing.T, exitCode int) http.Handler {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/abc/exec", func(w http.ResponseWriter, r *http.Request) {
+		var cfg execConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil || !cfg.AttachStdin {
+			http.Error(w, `{"message": "bad exec config"}`, http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"Id": "exec1"}`))
+	})
+	mux.HandleFunc("POST /exec/exec1/start", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "tcp" {
+			t.Error("expected upgrade request")
+		}
+
+		var start execStart
+		if err := json.NewDecoder(r.Body).Decode(&start); err != nil || start.Tty {
+			t.Errorf("unexpected exec start: %+v, %v", start, err)
+		}
+
+		conn, buf, err := w.(http.Hijacker).Hijack() //nolint:forcetypeassert
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		_ = buf.Flush()
+
+		in, err := io.ReadAll(buf)
+		if err != nil {
+			t.Error(err)
+		}
+
+		_, _ = conn.Write(frame(streamStdout, strings.ToUpper(string(in))))
+		_, _ = conn.Write(frame(streamStderr, "done\n"))
+	})
+	mux.HandleFunc("GET /exec/exec1/json", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(execInspect{Running: false, ExitCode: exitCode})
+	})
+	mux.HandleFunc("POST /containers/missing/exec", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message": "No such container: missing"}`, http.StatusNotFound)
+	})
+
+	return mux
+}
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, execHandler(t, 0))
+
+	var stdout, stderr bytes.Buffer
+	if err := client.Exec(context.Background(), "abc", ExecOptions{
+		Cmd:    []string{"tr", "a-z", "A-Z"},
+		Env:    nil,
+		Stdin:  strings.NewReader("select 1;\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff("SELECT 1;\n", stdout.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff("done\n", stderr.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	err := client.Exec(context.Background(), "missing", ExecOptions{}) //nolint:exhaustruct
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound ||
+		apiErr.Message != "No such container: missing" {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, execHandler(t, 3)) //nolint:mnd
+
+	err := client.Exec(context.Background(), "abc", ExecOptions{
+		Cmd:    []string{"false"},
+		Env:    nil,
+		Stdin:  strings.NewReader(""),
+		Stdout: nil,
+		Stderr: nil,
+	})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("expected exit code 3, got: %v", err)
+	}
+}
+
+func TestContainerList(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if got := r.URL.Query().Get("filters"); got != `{"label":["com.docker.compose.project=app"]}` {
+			t.Errorf("unexpected filters: %s", got)
+		}
+
+		if r.URL.Query().Get("all") != "1" {
+			t.Error("expected all containers to be requested")
+		}
+
+		_, _ = w.Write([]byte(`[{"Id": "abc", "Names": ["/app-auth-1"], "State": "running"}]`))
+	}))
+
+	containers, err := client.ContainerList(
+		context.Background(), true, "com.docker.compose.project=app",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(containers) != 1 || containers[0].Name() != "app-auth-1" {
+		t.Errorf("unexpected containers: %+v", containers)
+	}
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_ping" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte("OK"))
+	}))
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed: %s", err)
+	}
+
+	unreachable, err := New(&url.URL{Scheme: "unix", Path: "/nonexistent/docker.sock"}) //nolint:exhaustruct
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unreachable.Ping(context.Background()); err == nil {
+		t.Error("expected ping to fail for a missing socket")
+	}
+}
@@ -0,0 +1,166 @@
+//nolint:tagliatelle
+package dockerapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ExitError is returned when the command run with Exec exits with a non zero code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
+type ExecOptions struct {
+	Cmd    []string
+	Env    []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type execConfig struct {
+	AttachStdin  bool     `json:"AttachStdin"`
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+	Tty          bool     `json:"Tty"`
+	Env          []string `json:"Env"`
+	Cmd          []string `json:"Cmd"`
+}
+
+type execStart struct {
+	Detach bool `json:"Detach"`
+	Tty    bool `json:"Tty"`
+}
+
+type execInspect struct {
+	Running  bool `json:"Running"`
+	ExitCode int  `json:"ExitCode"`
+}
+
+// hijack sends the request and takes over the connection so it can be used to
+// send stdin and read the multiplexed output, which is read from the returned
+// reader as part of it may be buffered.
+func (c *Client) hijack(
+	ctx context.Context, path string, body any,
+) (net.Conn, io.Reader, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, path, nil, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		if err := checkResponse(resp); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	return conn, br, nil
+}
+
+// Exec runs a command in a running container and waits for it to finish. If
+// the command fails an *ExitError with its exit code is returned.
+func (c *Client) Exec(ctx context.Context, containerID string, opts ExecOptions) error {
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := c.do(
+		ctx,
+		http.MethodPost,
+		"/containers/"+containerID+"/exec",
+		nil,
+		execConfig{
+			AttachStdin:  opts.Stdin != nil,
+			AttachStdout: true,
+			AttachStderr: true,
+			Tty:          false,
+			Env:          opts.Env,
+			Cmd:          opts.Cmd,
+		},
+		&created,
+	); err != nil {
+		return fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	conn, r, err := c.hijack(
+		ctx, "/exec/"+created.ID+"/start", execStart{Detach: false, Tty: false},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start exec: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if opts.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(conn, opts.Stdin)
+
+			// let the command know there is no more input
+			if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+				_ = cw.CloseWrite()
+			}
+		}()
+	}
+
+	if err := demux(r, stdout, stderr); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("exec interrupted: %w", ctx.Err())
+		}
+
+		return err
+	}
+
+	var inspect execInspect
+	if err := c.do(
+		ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &inspect,
+	); err != nil {
+		return fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	if inspect.ExitCode != 0 {
+		return &ExitError{Code: inspect.ExitCode}
+	}
+
+	return nil
+}
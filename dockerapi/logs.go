@@ -0,0 +1,106 @@
+package dockerapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type LogsOptions struct {
+	Follow     bool
+	Timestamps bool
+	// Tail is the number of lines to show from the end of the logs or "all".
+	Tail string
+	// Since and Until accept the same formats as `docker logs`: a duration
+	// relative to now, an RFC 3339 timestamp or a unix timestamp.
+	Since string
+	Until string
+}
+
+// unixTimestamp converts the formats accepted by `docker logs --since` to the
+// unix timestamp the API expects.
+func unixTimestamp(value string, now time.Time) (string, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid time %q", value) //nolint:err113
+}
+
+func (o LogsOptions) query(now time.Time) (url.Values, error) {
+	query := url.Values{}
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	query.Set("follow", strconv.FormatBool(o.Follow))
+	query.Set("timestamps", strconv.FormatBool(o.Timestamps))
+
+	if o.Tail != "" {
+		query.Set("tail", o.Tail)
+	}
+
+	for key, value := range map[string]string{"since": o.Since, "until": o.Until} {
+		if value == "" {
+			continue
+		}
+
+		ts, err := unixTimestamp(value, now)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set(key, ts)
+	}
+
+	return query, nil
+}
+
+// ContainerLogs streams the logs of the container until they end or, when
+// following them, until the context is cancelled. tty must be set if the
+// container was started with a TTY as its output isn't multiplexed then.
+func (c *Client) ContainerLogs(
+	ctx context.Context,
+	id string,
+	tty bool,
+	opts LogsOptions,
+	stdout io.Writer,
+	stderr io.Writer,
+) error {
+	query, err := opts.query(time.Now())
+	if err != nil {
+		return err
+	}
+
+	r, err := c.stream(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get logs of container %s: %w", id, err)
+	}
+	defer r.Close()
+
+	if tty {
+		if _, err := io.Copy(stdout, r); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to read logs: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := demux(r, stdout, stderr); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
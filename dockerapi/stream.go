@@ -0,0 +1,49 @@
+package dockerapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	streamStdin = iota
+	streamStdout
+	streamStderr
+)
+
+const streamHeaderSize = 8
+
+// demux splits the multiplexed stream the API uses for the output of containers
+// without a TTY. Each frame starts with a header with the stream it belongs to
+// and the size of the payload.
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, streamHeaderSize)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read stream header: %w", err)
+		}
+
+		var w io.Writer
+
+		switch header[0] {
+		case streamStdin, streamStdout:
+			w = stdout
+		case streamStderr:
+			w = stderr
+		default:
+			return fmt.Errorf("unknown stream %d in output", header[0]) //nolint:err113
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+	}
+}
@@ -54,5 +54,7 @@ func ai(
 		},
 		Volumes:    nil,
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
@@ -109,6 +109,8 @@ func auth( //nolint:funlen
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 
 	if *cfg.Auth.Version != "0.0.0-dev" &&
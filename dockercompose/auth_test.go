@@ -200,6 +200,8 @@ func expectedAuth() *Service {
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
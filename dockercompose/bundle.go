@@ -0,0 +1,236 @@
+package dockercompose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	bundleFilesFolder = "files"
+	bundleDockerSock  = "${DOCKER_SOCKET:-/var/run/docker.sock}"
+)
+
+// bootstrapScript applies what `nhost up` applies once graphql is healthy.
+const bootstrapScript = `set -e
+if [ -d migrations/default ]; then
+  hasura-cli migrate apply --all-databases --skip-update-check
+fi
+if [ -f metadata/version.yaml ]; then
+  hasura-cli metadata apply --skip-update-check
+  hasura-cli metadata reload --skip-update-check
+fi
+`
+
+const bootstrapSeedsScript = `if [ -d seeds/default ]; then
+  hasura-cli seed apply --all-databases --skip-update-check
+fi
+`
+
+const bundleEnv = `# read by docker compose, traefik only routes to the containers of this project.
+# It differs from the project's name so the bundle doesn't take over the
+# containers and volumes of the environment started by ` + "`nhost up`" + `
+COMPOSE_PROJECT_NAME=%s
+# path of the docker socket traefik discovers the services through
+DOCKER_SOCKET=/var/run/docker.sock
+`
+
+// BundleProjectName returns the compose project name of the bundle exported from
+// the project projectName.
+func BundleProjectName(projectName string) string {
+	return projectName + "-bundle"
+}
+
+// bootstrap is a one-off service that applies the migrations, the metadata and,
+// optionally, the seeds without the CLI.
+func bootstrap(graphql *Service, nhostFolder string, applySeeds bool) (*Service, error) {
+	_, version, ok := strings.Cut(graphql.Image, ":")
+	if !ok {
+		return nil, fmt.Errorf("failed to get hasura version from image %s", graphql.Image) //nolint:err113
+	}
+
+	script := bootstrapScript
+	if applySeeds {
+		script += bootstrapSeedsScript
+	}
+
+	return &Service{
+		Image: HasuraCLIImage(version),
+		DependsOn: map[string]DependsOn{
+			"graphql": {Condition: "service_healthy"},
+		},
+		EntryPoint: nil,
+		Command:    []string{"bash", "-c", script},
+		Environment: map[string]string{
+			"HASURA_GRAPHQL_ENDPOINT":     "http://graphql:8080",
+			"HASURA_GRAPHQL_ADMIN_SECRET": graphql.Environment["HASURA_GRAPHQL_ADMIN_SECRET"],
+		},
+		ExtraHosts:  nil,
+		HealthCheck: nil,
+		Labels:      nil,
+		Ports:       nil,
+		Restart:     "no",
+		Volumes: []Volume{
+			{
+				Type:     "bind",
+				Source:   nhostFolder,
+				Target:   "/app",
+				ReadOnly: ptr(true),
+			},
+		},
+		WorkingDir: ptr("/app"),
+		Deploy:     nil,
+		Networks:   nil,
+	}, nil
+}
+
+func isWithin(folder, path string) (string, bool) {
+	rel, err := filepath.Rel(folder, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return rel, true
+}
+
+// copyPath copies the file or folder at src to dst replacing what was there.
+func copyPath(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", dst, err)
+	}
+
+	if info.IsDir() {
+		if err := os.CopyFS(dst, os.DirFS(src)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", src, err)
+		}
+
+		return nil
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to create folder for %s: %w", dst, err)
+	}
+
+	if err := os.WriteFile(dst, b, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	return nil
+}
+
+// bundleSource returns the path of a bind mount relative to the bundle. Files
+// generated in the .nhost folder and files outside of the project are copied
+// into the bundle, the rest of the project is referenced so it stays up to date.
+func bundleSource(v Volume, dir, rootFolder, dotNhostFolder string) (string, error) {
+	if v.Target == "/var/run/docker.sock" {
+		return bundleDockerSock, nil
+	}
+
+	var dst string
+
+	if rel, ok := isWithin(dotNhostFolder, v.Source); ok {
+		dst = filepath.Join(bundleFilesFolder, rel)
+	} else if _, ok := isWithin(rootFolder, v.Source); ok {
+		rel, err := filepath.Rel(dir, v.Source)
+		if err != nil {
+			return "", fmt.Errorf("failed to get relative path of %s: %w", v.Source, err)
+		}
+
+		if rel = filepath.ToSlash(rel); !strings.HasPrefix(rel, "../") {
+			rel = "./" + rel
+		}
+
+		return rel, nil
+	} else {
+		dst = filepath.Join(bundleFilesFolder, "external", filepath.Base(v.Source))
+	}
+
+	if err := copyPath(v.Source, filepath.Join(dir, dst)); err != nil {
+		return "", err
+	}
+
+	return "./" + filepath.ToSlash(dst), nil
+}
+
+// WriteBundle writes the compose file to dir as a project that runs with plain
+// `docker compose up`: bind mounts are relative to dir, the files generated
+// for the environment are copied into it and a bootstrap service applies
+// migrations, metadata and seeds. dir has to be inside the project as the
+// project files are referenced rather than copied. projectName is the name the
+// compose file was generated with, see BundleProjectName.
+func WriteBundle(
+	composeFile *ComposeFile,
+	dir, projectName, rootFolder, nhostFolder, dotNhostFolder string,
+	applySeeds bool,
+) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %s: %w", dir, err)
+	}
+
+	if _, ok := isWithin(rootFolder, dir); !ok {
+		return fmt.Errorf( //nolint:err113
+			"%s has to be inside the project at %s so the bundle can reference its files", dir, rootFolder,
+		)
+	}
+
+	if _, ok := isWithin(dotNhostFolder, dir); ok {
+		return errors.New("the bundle can't be written inside the .nhost folder") //nolint:err113
+	}
+
+	graphql, ok := composeFile.Services["graphql"]
+	if !ok {
+		return errors.New("graphql service not found") //nolint:err113
+	}
+
+	bootstrap, err := bootstrap(graphql, nhostFolder, applySeeds)
+	if err != nil {
+		return err
+	}
+
+	composeFile.Services["bootstrap"] = bootstrap
+
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	for _, svc := range composeFile.Services {
+		for i, v := range svc.Volumes {
+			if v.Type != "bind" {
+				continue
+			}
+
+			source, err := bundleSource(v, dir, rootFolder, dotNhostFolder)
+			if err != nil {
+				return err
+			}
+
+			svc.Volumes[i].Source = source
+		}
+	}
+
+	b, err := yaml.Marshal(composeFile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal docker-compose file: %w", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, "docker-compose.yaml"), string(b)); err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(dir, ".env"), fmt.Sprintf(bundleEnv, projectName))
+}
@@ -0,0 +1,155 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteBundle(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	root := t.TempDir()
+	nhostFolder := filepath.Join(root, "nhost")
+	dotNhostFolder := filepath.Join(root, ".nhost")
+	external := filepath.Join(t.TempDir(), "ca.crt")
+
+	for path, content := range map[string]string{
+		filepath.Join(dotNhostFolder, "traefik", "traefik.yaml"):   "tls: {}",
+		filepath.Join(dotNhostFolder, "traefik", "certs", "a.crt"): "cert",
+		filepath.Join(nhostFolder, "config.yaml"):                  "version: 3",
+		external: "ca",
+	} {
+		if err := writeFile(path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	composeFile := func() *ComposeFile {
+		return &ComposeFile{
+			Services: map[string]*Service{
+				"traefik": { //nolint:exhaustruct
+					Image: "traefik:v3.1",
+					Volumes: []Volume{
+						{
+							Type:     "bind",
+							Source:   filepath.Join(dotNhostFolder, "traefik"),
+							Target:   "/opt/traefik",
+							ReadOnly: ptr(true),
+						},
+						{
+							Type:     "bind",
+							Source:   "/home/user/.docker/run/docker.sock",
+							Target:   "/var/run/docker.sock",
+							ReadOnly: ptr(true),
+						},
+					},
+				},
+				"graphql": { //nolint:exhaustruct
+					Image:       "nhost/graphql-engine:v2.36.0",
+					Environment: map[string]string{"HASURA_GRAPHQL_ADMIN_SECRET": "nhost-admin-secret"},
+					Volumes: []Volume{
+						{Type: "bind", Source: external, Target: "/opt/nhost/ca/ca.crt", ReadOnly: ptr(true)},
+					},
+				},
+				"functions": { //nolint:exhaustruct
+					Image: "nhost/functions:1.0.0",
+					Volumes: []Volume{
+						{Type: "bind", Source: root, Target: "/opt/project", ReadOnly: nil},
+						{Type: "volume", Source: "functions_node_modules", Target: "/opt/project/node_modules", ReadOnly: nil},
+					},
+				},
+			},
+			Volumes:  map[string]struct{}{"functions_node_modules": {}},
+			Networks: nil,
+		}
+	}
+
+	dir := filepath.Join(root, "ci", "nhost")
+	if err := WriteBundle(
+		composeFile(), dir, BundleProjectName("myproject"), root, nhostFolder, dotNhostFolder, true,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "docker-compose.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got ComposeFile
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string][]string{}
+	for name, svc := range got.Services {
+		for _, v := range svc.Volumes {
+			sources[name] = append(sources[name], v.Source)
+		}
+	}
+
+	expected := map[string][]string{
+		"traefik":   {"./files/traefik", "${DOCKER_SOCKET:-/var/run/docker.sock}"},
+		"graphql":   {"./files/external/ca.crt"},
+		"functions": {"../..", "functions_node_modules"},
+		"bootstrap": {"../../nhost"},
+	}
+	if diff := cmp.Diff(expected, sources); diff != "" {
+		t.Error(diff)
+	}
+
+	bootstrap := got.Services["bootstrap"]
+	if bootstrap.Image != "nhost/graphql-engine:v2.36.0.cli-migrations-v3" {
+		t.Errorf("unexpected bootstrap image %s", bootstrap.Image)
+	}
+
+	if bootstrap.Restart != "no" {
+		t.Errorf("unexpected bootstrap restart policy %s", bootstrap.Restart)
+	}
+
+	if bootstrap.Environment["HASURA_GRAPHQL_ADMIN_SECRET"] != "nhost-admin-secret" {
+		t.Error("expected bootstrap to use graphql's admin secret")
+	}
+
+	if !strings.Contains(bootstrap.Command[2], "hasura-cli seed apply") {
+		t.Error("expected bootstrap to apply seeds")
+	}
+
+	for _, f := range []string{
+		filepath.Join("files", "traefik", "traefik.yaml"),
+		filepath.Join("files", "traefik", "certs", "a.crt"),
+		filepath.Join("files", "external", "ca.crt"),
+		".env",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("expected %s in the bundle: %s", f, err)
+		}
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(env), "COMPOSE_PROJECT_NAME=myproject-bundle\n") {
+		t.Errorf("expected the bundle to run as its own project: %s", env)
+	}
+
+	// writing again replaces the copied files
+	if err := WriteBundle(
+		composeFile(), dir, "myproject", root, nhostFolder, dotNhostFolder, false,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteBundle(
+		composeFile(), t.TempDir(), "myproject", root, nhostFolder, dotNhostFolder, false,
+	); err == nil {
+		t.Error("expected error writing the bundle outside of the project")
+	}
+}
@@ -2,10 +2,10 @@ package dockercompose
 
 import (
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -23,6 +23,7 @@ const (
 	consolePort      = 9695
 	postgresPort     = 5432
 	configserverPort = 8088
+	clamavPort       = 3310
 )
 
 const (
@@ -57,8 +58,14 @@ func ports(host, container uint) []Port {
 }
 
 type ComposeFile struct {
-	Services map[string]*Service `yaml:"services"`
-	Volumes  map[string]struct{} `yaml:"volumes"`
+	Services map[string]*Service        `yaml:"services"`
+	Volumes  map[string]struct{}        `yaml:"volumes"`
+	Networks map[string]*ComposeNetwork `yaml:"networks,omitempty"`
+}
+
+type ComposeNetwork struct {
+	Name     string `yaml:"name,omitempty"`
+	External bool   `yaml:"external,omitempty"`
 }
 
 //nolint:tagliatelle
@@ -75,6 +82,27 @@ type Service struct {
 	Restart     string               `yaml:"restart"`
 	Volumes     []Volume             `yaml:"volumes,omitempty"`
 	WorkingDir  *string              `yaml:"working_dir,omitempty"`
+	Deploy      *Deploy              `yaml:"deploy,omitempty"`
+	Networks    map[string]*Network  `yaml:"networks,omitempty"`
+}
+
+type Network struct {
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+type Deploy struct {
+	Replicas  uint8      `yaml:"replicas,omitempty"`
+	Resources *Resources `yaml:"resources,omitempty"`
+}
+
+type Resources struct {
+	Limits       *ResourceLimits `yaml:"limits,omitempty"`
+	Reservations *ResourceLimits `yaml:"reservations,omitempty"`
+}
+
+type ResourceLimits struct {
+	CPUs   string `yaml:"cpus,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
 }
 
 type DependsOn struct {
@@ -162,7 +190,7 @@ func dumpCert(
 	f2, err := os.OpenFile(
 		filepath.Join(dotnhostfolder, "traefik", "certs", dstName+".key"),
 		os.O_TRUNC|os.O_CREATE|os.O_WRONLY,
-		0o644, //nolint:mnd
+		0o600, //nolint:mnd
 	)
 	if err != nil {
 		return fmt.Errorf("failed to open local.key: %w", err)
@@ -206,68 +234,84 @@ func trafikFiles(dotnhostfolder string) error {
 	return nil
 }
 
-func getDockerHost() (*url.URL, error) {
-	socket, ok := os.LookupEnv("DOCKER_HOST")
-	if !ok {
-		u, _ := url.Parse("unix:///var/run/docker.sock")
-		return u, nil
+// traefik discovers services through the runtime's docker compatible API, if
+// the runtime doesn't have one it only reads the routes written to its folder.
+func traefik(
+	subdomain, projectName string, port uint, dotnhostfolder string, runtime Runtime,
+) (*Service, error) {
+	if err := trafikFiles(dotnhostfolder); err != nil {
+		return nil, fmt.Errorf("failed to create traefik files: %w", err)
 	}
 
-	u, err := url.Parse(socket)
+	svc, err := traefikService(
+		port,
+		dotnhostfolder,
+		fmt.Sprintf("Label(`com.docker.compose.project`,`%s`)", projectName),
+		runtime,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DOCKER_HOST: %w", err)
+		return nil, err
 	}
 
-	return u, nil
-}
+	svc.ExtraHosts = extraHosts(subdomain)
 
-func traefik(subdomain, projectName string, port uint, dotnhostfolder string) (*Service, error) {
-	if err := trafikFiles(dotnhostfolder); err != nil {
-		return nil, fmt.Errorf("failed to create traefik files: %w", err)
-	}
+	return svc, nil
+}
 
-	dockerURL, err := getDockerHost()
+// traefikService returns a traefik listening on port that reads its
+// configuration from folder and only routes to the containers matching the
+// constraint.
+func traefikService(
+	port uint, folder string, constraint string, runtime Runtime,
+) (*Service, error) {
+	dockerURL, err := runtime.SocketURL()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get docker host: %w", err)
+		return nil, fmt.Errorf("failed to get %s socket: %w", runtime.Name(), err)
 	}
 
 	volumes := []Volume{{
 		Type:     "bind",
-		Source:   filepath.Join(dotnhostfolder, "traefik"),
+		Source:   filepath.Join(folder, "traefik"),
 		Target:   "/opt/traefik",
 		ReadOnly: ptr(true),
 	}}
 
-	dockerEndpoint := dockerURL.String()
-	if dockerURL.Scheme == "unix" {
-		volumes = append(volumes, Volume{
-			Type:     "bind",
-			Source:   dockerURL.Path,
-			Target:   "/var/run/docker.sock",
-			ReadOnly: ptr(true),
-		})
-		dockerEndpoint = "unix:///var/run/docker.sock"
+	command := []string{
+		"--api.insecure=true",
+		"--providers.file.directory=/opt/traefik",
+		"--providers.file.watch=true",
 	}
 
-	return &Service{
-		Image:      "traefik:v3.1",
-		DependsOn:  nil,
-		EntryPoint: nil,
-		Command: []string{
-			"--api.insecure=true",
+	if dockerURL != nil {
+		dockerEndpoint := dockerURL.String()
+		if dockerURL.Scheme == "unix" {
+			volumes = append(volumes, Volume{
+				Type:     "bind",
+				Source:   dockerURL.Path,
+				Target:   "/var/run/docker.sock",
+				ReadOnly: ptr(true),
+			})
+			dockerEndpoint = "unix:///var/run/docker.sock"
+		}
+
+		command = append(
+			command,
 			"--providers.docker=true",
-			"--providers.docker.endpoint=" + dockerEndpoint,
-			"--providers.file.directory=/opt/traefik",
-			"--providers.file.watch=true",
+			"--providers.docker.endpoint="+dockerEndpoint,
 			"--providers.docker.exposedbydefault=false",
-			fmt.Sprintf(
-				"--providers.docker.constraints=Label(`com.docker.compose.project`,`%s`)",
-				projectName,
-			),
-			fmt.Sprintf("--entrypoints.web.address=:%d", port),
-		},
+			"--providers.docker.constraints="+constraint,
+		)
+	}
+
+	command = append(command, fmt.Sprintf("--entrypoints.web.address=:%d", port))
+
+	return &Service{
+		Image:       "traefik:v3.1",
+		DependsOn:   nil,
+		EntryPoint:  nil,
+		Command:     command,
 		Environment: nil,
-		ExtraHosts:  extraHosts(subdomain),
+		ExtraHosts:  nil,
 		HealthCheck: nil,
 		Labels:      nil,
 		Ports: []Port{
@@ -281,6 +325,8 @@ func traefik(subdomain, projectName string, port uint, dotnhostfolder string) (*
 		Restart:    "always",
 		Volumes:    volumes,
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}, nil
 }
 
@@ -310,6 +356,8 @@ func minio(subdomain, volumeName string) *Service {
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -363,6 +411,8 @@ func dashboard(
 		Restart:    "",
 		Volumes:    []Volume{},
 		WorkingDir: new(string),
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -445,6 +495,8 @@ func functions( //nolint:funlen
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -484,6 +536,8 @@ func mailhog(subdomain, volumeName string, useTLS bool) *Service {
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -500,6 +554,11 @@ func sanitizeBranch(name string) string {
 	return strings.ToLower(re.ReplaceAllString(name, ""))
 }
 
+// PostgresVolume returns the name of the volume holding the database of branch.
+func PostgresVolume(branch string) string {
+	return "pgdata_" + sanitizeBranch(branch)
+}
+
 func IsJWTSecretCompatibleWithHasuraAuth( //nolint:cyclop
 	jwtSecret *model.ConfigJWTSecret,
 ) bool {
@@ -528,6 +587,9 @@ func getServices( //nolint: funlen,cyclop
 	dashboardVersion string,
 	configserviceImage string,
 	startFunctions bool,
+	without []string,
+	localDomain *LocalDomain,
+	runtime Runtime,
 	runServices ...*RunService,
 ) (map[string]*Service, error) {
 	minioVolumeName := "minio_" + sanitizeBranch(branch)
@@ -538,7 +600,7 @@ func getServices( //nolint: funlen,cyclop
 		return nil, err
 	}
 
-	pgVolumeName := "pgdata_" + sanitizeBranch(branch)
+	pgVolumeName := PostgresVolume(branch)
 	dataFolder := filepath.Join(dotNhostFolder, "data")
 
 	postgres, err := postgres(cfg, subdomain, postgresPort, dataFolder, pgVolumeName)
@@ -558,7 +620,7 @@ func getServices( //nolint: funlen,cyclop
 		return nil, err
 	}
 
-	traefik, err := traefik(subdomain, projectName, httpPort, dotNhostFolder)
+	traefik, err := traefik(subdomain, projectName, httpPort, dotNhostFolder, runtime)
 	if err != nil {
 		return nil, err
 	}
@@ -583,6 +645,35 @@ func getServices( //nolint: funlen,cyclop
 			runServices...),
 	}
 
+	if localAntivirus(cfg) {
+		services["clamav"] = clamav(subdomain)
+	}
+
+	// the schema always fills in grafana so it is started only when asked for
+	if grafanaCfg := cfg.GetObservability().GetGrafana(); grafanaCfg != nil &&
+		!slices.Contains(without, "observability") {
+		prometheus, err := prometheus(subdomain, dotNhostFolder)
+		if err != nil {
+			return nil, err
+		}
+
+		grafana, err := grafana(
+			grafanaCfg,
+			subdomain,
+			httpPort,
+			useTLS,
+			dotNhostFolder,
+			"grafana_"+sanitizeBranch(branch),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		services["prometheus"] = prometheus
+		services["postgres-exporter"] = postgresExporter(subdomain)
+		services["grafana"] = grafana
+	}
+
 	if startFunctions {
 		services["functions"] = functions(
 			cfg,
@@ -606,13 +697,40 @@ func getServices( //nolint: funlen,cyclop
 
 		services["auth"] = auth
 
+		if smsEnabled(cfg) {
+			dir, err := smsCatcherFiles(dotNhostFolder, localDomain)
+			if err != nil {
+				return nil, err
+			}
+
+			smsAuthPatch(auth, projectName, dir)
+			services["sms"] = smsCatcher(configserviceImage, useTLS, projectName, dir)
+		}
+
 		if cfg.Ai != nil {
 			services["ai"] = ai(cfg, subdomain)
 		}
 	}
 
+	pruneServices(services, without)
+
+	for _, runService := range runServices {
+		svc := run(runService.Config, subdomain, branch, useTLS, runService.EnforceResources)
+		if svc.Deploy != nil && svc.Deploy.Replicas > 1 && len(svc.Ports) > 0 {
+			return nil, fmt.Errorf( //nolint:err113
+				"run service %s publishes ports on the host, which can't be shared by %d replicas",
+				runService.Config.Name,
+				svc.Deploy.Replicas,
+			)
+		}
+
+		services["run-"+runService.Config.Name] = svc
+	}
+
 	for _, runService := range runServices {
-		services["run-"+runService.Config.Name] = run(runService.Config, subdomain, branch)
+		if err := runDependsOn(services, runService); err != nil {
+			return nil, err
+		}
 	}
 
 	return services, nil
@@ -621,6 +739,10 @@ func getServices( //nolint: funlen,cyclop
 type RunService struct {
 	Config *model.ConfigRunServiceConfig
 	Path   string
+	// DependsOn lists the services that need to be healthy before the run service starts
+	DependsOn []string
+	// EnforceResources applies the compute resources and replicas of the config
+	EnforceResources bool
 }
 
 func mountCACertificates(
@@ -653,6 +775,9 @@ func ComposeFileFromConfig(
 	configserverImage string,
 	startFunctions bool,
 	caCertificatesPath string,
+	without []string,
+	localDomain *LocalDomain,
+	runtime Runtime,
 	runServices ...*RunService,
 ) (*ComposeFile, error) {
 	services, err := getServices(
@@ -670,21 +795,44 @@ func ComposeFileFromConfig(
 		dashboardVersion,
 		configserverImage,
 		startFunctions,
+		without,
+		localDomain,
+		runtime,
 		runServices...,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	volumes := map[string]struct{}{
-		rootNodeModules(branch):             {},
-		"pgdata_" + sanitizeBranch(branch):  {},
-		"minio_" + sanitizeBranch(branch):   {},
-		"mailhog_" + sanitizeBranch(branch): {},
+	if localDomain != nil {
+		if err := localDomainFiles(localDomain, dotNhostFolder, runServices...); err != nil {
+			return nil, err
+		}
+
+		adaptToDomain(services, localDomain)
 	}
 
-	if startFunctions {
-		volumes[functionsNodeModules(branch)] = struct{}{}
+	if err := adaptToRuntime(services, runtime, dotNhostFolder); err != nil {
+		return nil, err
+	}
+
+	volumes := map[string]struct{}{
+		rootNodeModules(branch): {},
+		PostgresVolume(branch):  {},
+	}
+
+	// only the volumes of the services that are part of the environment
+	for service, volume := range map[string]string{
+		"minio":     "minio_" + sanitizeBranch(branch),
+		"mailhog":   "mailhog_" + sanitizeBranch(branch),
+		"functions": functionsNodeModules(branch),
+		"grafana":   "grafana_" + sanitizeBranch(branch),
+		// virus signatures aren't branch specific so they are shared
+		"clamav": "clamav",
+	} {
+		if _, ok := services[service]; ok {
+			volumes[volume] = struct{}{}
+		}
 	}
 
 	for _, runService := range runServices {
@@ -697,8 +845,14 @@ func ComposeFileFromConfig(
 		mountCACertificates(caCertificatesPath, services)
 	}
 
+	var networks map[string]*ComposeNetwork
+	if _, ok := services["sms"]; ok {
+		networks = map[string]*ComposeNetwork{smsNetwork: {Name: "", External: false}}
+	}
+
 	return &ComposeFile{
 		Services: services,
 		Volumes:  volumes,
+		Networks: networks,
 	}, nil
 }
@@ -101,8 +101,9 @@ func getServicesCloud(
 	ports ExposePorts,
 	dashboardVersion string,
 	configserviceImage string,
+	runtime Runtime,
 ) (map[string]*Service, error) {
-	traefik, err := traefik(subdomain, projectName, httpPort, dotNhostFolder)
+	traefik, err := traefik(subdomain, projectName, httpPort, dotNhostFolder, runtime)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +165,7 @@ func CloudComposeFileFromConfig(
 	dashboardVersion string,
 	configserverImage string,
 	caCertificatesPath string,
+	runtime Runtime,
 ) (*ComposeFile, error) {
 	services, err := getServicesCloud(
 		cfg,
@@ -181,11 +183,16 @@ func CloudComposeFileFromConfig(
 		ports,
 		dashboardVersion,
 		configserverImage,
+		runtime,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := adaptToRuntime(services, runtime, dotNhostFolder); err != nil {
+		return nil, err
+	}
+
 	if caCertificatesPath != "" {
 		mountCACertificates(caCertificatesPath, services)
 	}
@@ -193,5 +200,6 @@ func CloudComposeFileFromConfig(
 	return &ComposeFile{
 		Services: services,
 		Volumes:  nil,
+		Networks: nil,
 	}, nil
 }
@@ -78,5 +78,7 @@ func configserver( //nolint: funlen
 			bindings...,
 		),
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
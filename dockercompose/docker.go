@@ -5,30 +5,52 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/creack/pty"
+	"golang.org/x/term"
 )
 
-type Docker struct{}
+type Docker struct {
+	runtime     Runtime
+	localDomain *LocalDomain
+}
 
-func NewDocker() *Docker {
-	return &Docker{}
+func NewDocker(runtime Runtime) *Docker {
+	return &Docker{
+		runtime:     runtime,
+		localDomain: nil,
+	}
 }
 
-func (d *Docker) HasuraWrapper(
+// WithLocalDomain returns a Docker whose containers reach the environment
+// served at the local domain.
+func (d *Docker) WithLocalDomain(localDomain *LocalDomain) *Docker {
+	return &Docker{
+		runtime:     d.runtime,
+		localDomain: localDomain,
+	}
+}
+
+// hasuraCommand runs hasura-cli in a container with the nhost folder as its
+// working directory and the environment's hosts resolvable. volumes are mounted
+// in the format of `docker run -v`.
+func (d *Docker) hasuraCommand(
 	ctx context.Context,
+	interactive bool,
+	volumes []string,
 	subdomain,
 	nhostfolder,
 	hasuraVersion string,
-	exrtaArgs ...string,
-) error {
+	extraArgs ...string,
+) (*exec.Cmd, error) {
 	absPath, err := filepath.Abs(nhostfolder)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
 	args := []string{
@@ -36,24 +58,79 @@ func (d *Docker) HasuraWrapper(
 		"-v", absPath + ":/app",
 		"-e", "HASURA_GRAPHQL_ENABLE_TELEMETRY=false",
 		"-w", "/app",
-		"-it", "--rm",
+		"--rm",
 		"--entrypoint", "hasura-cli",
 	}
 
-	for _, host := range extraHosts(subdomain) {
-		args = append(args, "--add-host", host)
+	if interactive {
+		args = append(args, "-it")
+	}
+
+	for _, v := range volumes {
+		args = append(args, "-v", v)
+	}
+
+	hosts := extraHosts(subdomain)
+	if d.localDomain != nil {
+		replacer := d.localDomain.replacer()
+		for i, host := range hosts {
+			hosts[i] = replacer.Replace(host)
+		}
+
+		args = append(
+			args,
+			"-v", filepath.Join(d.localDomain.CAFolder, "ca.crt")+":/opt/nhost/ca/ca.crt:ro",
+			"-e", "SSL_CERT_DIR=/etc/ssl/certs:/opt/nhost/ca",
+		)
+	}
+
+	gateway := d.runtime.HostGateway()
+	for _, host := range hosts {
+		args = append(args, "--add-host", strings.Replace(host, "host-gateway", gateway, 1))
 	}
 
 	args = append(
 		args,
-		fmt.Sprintf("nhost/graphql-engine:%s.cli-migrations-v3", hasuraVersion),
+		HasuraCLIImage(hasuraVersion),
 	)
 
-	cmd := exec.CommandContext( //nolint:gosec
+	return exec.CommandContext( //nolint:gosec
 		ctx,
-		"docker",
-		append(args, exrtaArgs...)...,
+		d.runtime.Binary(),
+		append(args, extraArgs...)...,
+	), nil
+}
+
+// HasuraWrapper runs hasura-cli with the output in the terminal. A pty is only
+// used when stdin is a terminal so prompts work, otherwise the output is passed
+// through as is. The exit status of hasura-cli is returned in both cases.
+func (d *Docker) HasuraWrapper(
+	ctx context.Context,
+	subdomain,
+	nhostfolder,
+	hasuraVersion string,
+	exrtaArgs ...string,
+) error {
+	interactive := term.IsTerminal(int(os.Stdin.Fd())) //nolint:gosec
+
+	cmd, err := d.hasuraCommand(
+		ctx, interactive, nil, subdomain, nhostfolder, hasuraVersion, exrtaArgs...,
 	)
+	if err != nil {
+		return err
+	}
+
+	if !interactive {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("hasura-cli failed: %w", err)
+		}
+
+		return nil
+	}
 
 	f, err := pty.Start(cmd)
 	if err != nil {
@@ -61,15 +138,16 @@ func (d *Docker) HasuraWrapper(
 	}
 	defer f.Close()
 
-	if n, err := io.Copy(os.Stdout, f); err != nil {
-		var pathError *fs.PathError
-		switch {
-		case errors.As(err, &pathError) && n > 0 && pathError.Op == op:
-			// linux pty returns an error when the process exits
-			return nil
-		default:
-			return fmt.Errorf("failed to copy pty output: %w", err)
-		}
+	// linux pty returns EIO when the process exits, its status comes from Wait
+	if _, err := io.Copy(os.Stdout, f); err != nil && !errors.Is(err, syscall.EIO) {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return fmt.Errorf("failed to copy pty output: %w", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("hasura-cli failed: %w", err)
 	}
 
 	return nil
@@ -1,34 +1,88 @@
 package dockercompose
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/creack/pty"
+	"github.com/nhost/cli/dockerapi"
 	"gopkg.in/yaml.v3"
 )
 
-const op = "read"
-
+// DockerCompose manages the project. Creating and removing it is left to the
+// runtime's compose CLI, everything else goes through the Docker Engine API if
+// the runtime provides a compatible one and falls back to the CLI otherwise.
 type DockerCompose struct {
+	runtime     Runtime
+	api         *dockerapi.Client
 	workingDir  string
 	filepath    string
 	projectName string
 }
 
-func New(workingDir, filepath, projectName string) *DockerCompose {
+func New(runtime Runtime, workingDir, filepath, projectName string) *DockerCompose {
 	return &DockerCompose{
+		runtime:     runtime,
+		api:         newAPIClient(runtime),
 		workingDir:  workingDir,
 		filepath:    filepath,
 		projectName: projectName,
 	}
 }
 
+const apiPingTimeout = 2 * time.Second
+
+// apiSocketURL returns the API the CLI talks to. Docker's is the one of the
+// active context unless DOCKER_HOST is set, like the docker CLI does.
+func apiSocketURL(runtime Runtime) (*url.URL, error) {
+	if _, ok := runtime.(*DockerRuntime); ok && os.Getenv("DOCKER_HOST") == "" {
+		if u, err := dockerContextURL(); err == nil && u != nil {
+			return u, nil
+		}
+	}
+
+	return runtime.SocketURL()
+}
+
+// newAPIClient returns nil if the API can't be reached so the CLI, which
+// resolves the endpoint on its own, is used instead.
+func newAPIClient(runtime Runtime) *dockerapi.Client {
+	socket, err := apiSocketURL(runtime)
+	if err != nil || socket == nil {
+		return nil
+	}
+
+	client, err := dockerapi.New(socket)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiPingTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx); err != nil {
+		return nil
+	}
+
+	return client
+}
+
+// Runtime returns the container runtime used to run the project.
+func (dc *DockerCompose) Runtime() Runtime {
+	return dc.runtime
+}
+
 func (dc *DockerCompose) WriteComposeFile(composeFile *ComposeFile) error {
 	f, err := os.Create(dc.filepath)
 	if err != nil {
@@ -48,17 +102,51 @@ func (dc *DockerCompose) WriteComposeFile(composeFile *ComposeFile) error {
 	return nil
 }
 
-func (dc *DockerCompose) Start(ctx context.Context) error {
-	cmd := exec.CommandContext( //nolint:gosec
+// ReadComposeFile reads the compose file written by a previous run. It returns
+// nil without error if the file doesn't exist.
+func (dc *DockerCompose) ReadComposeFile() (*ComposeFile, error) {
+	b, err := os.ReadFile(dc.filepath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil //nolint:nilnil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker-compose file: %w", err)
+	}
+
+	var composeFile ComposeFile
+	if err := yaml.Unmarshal(b, &composeFile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal docker-compose file: %w", err)
+	}
+
+	return &composeFile, nil
+}
+
+func (dc *DockerCompose) command(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext( //nolint:gosec
 		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"up",
-		"-d", "--wait",
-		"--remove-orphans",
+		dc.runtime.Binary(),
+		append(
+			[]string{
+				"compose",
+				"--project-directory", dc.workingDir,
+				"-f", dc.filepath,
+				"-p", dc.projectName,
+			},
+			args...,
+		)...,
 	)
+}
+
+func (dc *DockerCompose) Start(ctx context.Context) error {
+	// with the API we wait ourselves so errors say which service failed
+	cliWait := dc.api == nil && dc.runtime.SupportsWait()
+
+	cmd := dc.command(ctx, "up", "-d", "--remove-orphans")
+	if cliWait {
+		cmd.Args = append(cmd.Args, "--wait")
+	}
+
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -66,18 +154,61 @@ func (dc *DockerCompose) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start docker compose: %w", err)
 	}
 
+	if !cliWait {
+		return dc.wait(ctx)
+	}
+
 	return nil
 }
 
+// wait replaces `up --wait`, it waits until every container is running and
+// healthy if it has a healthcheck.
+func (dc *DockerCompose) wait(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		statuses, err := dc.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		ready, err := containersReady(statuses)
+		if err != nil {
+			return err
+		}
+
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for services to be ready: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func containersReady(statuses []ContainerStatus) (bool, error) {
+	ready := len(statuses) > 0
+
+	for _, s := range statuses {
+		switch {
+		case s.State == "exited" || s.State == "dead":
+			return false, fmt.Errorf("container %s is %s", s.Name, s.State) //nolint:err113
+		case s.Health == "unhealthy":
+			return false, fmt.Errorf("container %s is unhealthy", s.Name) //nolint:err113
+		case s.State != "running" || (s.Health != "" && s.Health != "healthy"):
+			ready = false
+		}
+	}
+
+	return ready, nil
+}
+
 func (dc *DockerCompose) Stop(ctx context.Context, volumes bool) error {
-	cmd := exec.CommandContext( //nolint:gosec
-		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"down",
-	)
+	cmd := dc.command(ctx, "down")
 	if volumes {
 		cmd.Args = append(cmd.Args, "--volumes")
 	}
@@ -92,22 +223,230 @@ func (dc *DockerCompose) Stop(ctx context.Context, volumes bool) error {
 	return nil
 }
 
-func (dc *DockerCompose) Logs(ctx context.Context, extraArgs ...string) error {
-	args := []string{
-		"compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"logs",
+// containers returns the containers of the project, of the given services if
+// any is passed.
+func (dc *DockerCompose) containers(
+	ctx context.Context, all bool, services ...string,
+) ([]dockerapi.Container, error) {
+	containers, err := dc.api.ContainerList(ctx, all, labelComposeProject+"="+dc.projectName)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
 	}
-	args = append(args, extraArgs...)
 
-	cmd := exec.CommandContext(
-		ctx,
-		"docker",
-		args...,
+	if len(services) == 0 {
+		return containers, nil
+	}
+
+	return slices.DeleteFunc(containers, func(c dockerapi.Container) bool {
+		return !slices.Contains(services, c.Labels[labelComposeService])
+	}), nil
+}
+
+// RunningServices returns the services of the project that are currently running.
+func (dc *DockerCompose) RunningServices(ctx context.Context) ([]string, error) {
+	if dc.api == nil {
+		cmd := dc.command(ctx, "ps", "--services", "--status", "running")
+		cmd.Stderr = os.Stderr
+
+		b, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list running services: %w", err)
+		}
+
+		return strings.Fields(string(b)), nil
+	}
+
+	containers, err := dc.containers(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running services: %w", err)
+	}
+
+	services := make([]string, 0, len(containers))
+	for _, c := range containers {
+		if service := c.Labels[labelComposeService]; !slices.Contains(services, service) {
+			services = append(services, service)
+		}
+	}
+
+	slices.Sort(services)
+
+	return services, nil
+}
+
+type Publisher struct {
+	URL           string `json:"URL"`
+	TargetPort    uint   `json:"TargetPort"`
+	PublishedPort uint   `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+type ContainerStatus struct {
+	Name       string      `json:"Name"`
+	Service    string      `json:"Service"`
+	State      string      `json:"State"`
+	Health     string      `json:"Health"`
+	Image      string      `json:"Image"`
+	Publishers []Publisher `json:"Publishers"`
+}
+
+// parseContainerStatus parses the output of `docker compose ps --format json`,
+// older versions of docker compose print a JSON array while newer ones print a
+// JSON object per line.
+func parseContainerStatus(b []byte) ([]ContainerStatus, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return []ContainerStatus{}, nil
+	}
+
+	var statuses []ContainerStatus
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &statuses); err != nil {
+			return nil, fmt.Errorf("failed to parse container status: %w", err)
+		}
+
+		return statuses, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var status ContainerStatus
+		if err := dec.Decode(&status); err != nil {
+			return nil, fmt.Errorf("failed to parse container status: %w", err)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Status returns the status of every container of the project, including stopped ones.
+func (dc *DockerCompose) Status(ctx context.Context) ([]ContainerStatus, error) {
+	if dc.api == nil {
+		cmd := dc.command(ctx, "ps", "--all", "--format", "json")
+		cmd.Stderr = os.Stderr
+
+		b, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status from docker compose: %w", err)
+		}
+
+		return parseContainerStatus(b)
+	}
+
+	containers, err := dc.containers(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of containers: %w", err)
+	}
+
+	statuses := make([]ContainerStatus, 0, len(containers))
+
+	for _, c := range containers {
+		inspect, err := dc.api.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		statuses = append(statuses, containerStatus(c, inspect.HealthStatus()))
+	}
+
+	return statuses, nil
+}
+
+func containerStatus(c dockerapi.Container, health string) ContainerStatus {
+	publishers := make([]Publisher, 0, len(c.Ports))
+	for _, p := range c.Ports {
+		publishers = append(publishers, Publisher{
+			URL:           p.IP,
+			TargetPort:    p.PrivatePort,
+			PublishedPort: p.PublicPort,
+			Protocol:      p.Type,
+		})
+	}
+
+	return ContainerStatus{
+		Name:       c.Name(),
+		Service:    c.Labels[labelComposeService],
+		State:      c.State,
+		Health:     health,
+		Image:      c.Image,
+		Publishers: publishers,
+	}
+}
+
+type LogsOptions = dockerapi.LogsOptions
+
+// Logs writes the logs of the given services, or of all of them if none is
+// passed, prefixing each line with the container it comes from like
+// `docker compose logs --no-color` does.
+func (dc *DockerCompose) Logs(
+	ctx context.Context, stdout io.Writer, opts LogsOptions, services ...string,
+) error {
+	if dc.api == nil {
+		return dc.logsCLI(ctx, stdout, opts, services...)
+	}
+
+	containers, err := dc.containers(ctx, true, services...)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
 	)
-	cmd.Stdout = os.Stdout
+
+	errs := make([]error, len(containers))
+
+	for i, c := range containers {
+		inspect, err := dc.api.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		w := &prefixWriter{prefix: c.Name() + "  | ", mu: &mu, w: stdout, buf: nil}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = dc.api.ContainerLogs(ctx, c.ID, inspect.Config.Tty, opts, w, w)
+			w.Flush()
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (dc *DockerCompose) logsCLI(
+	ctx context.Context, stdout io.Writer, opts LogsOptions, services ...string,
+) error {
+	args := []string{"logs", "--no-color"}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+
+	if opts.Until != "" {
+		args = append(args, "--until", opts.Until)
+	}
+
+	cmd := dc.command(ctx, append(args, services...)...)
+	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
@@ -117,44 +456,56 @@ func (dc *DockerCompose) Logs(ctx context.Context, extraArgs ...string) error {
 	return nil
 }
 
-func (dc *DockerCompose) Wrapper(ctx context.Context, extraArgs ...string) error {
-	args := []string{
-		"compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
+// prefixWriter writes complete lines to w with a prefix, several writers can
+// share w as long as they share the mutex too.
+type prefixWriter struct {
+	prefix string
+	mu     *sync.Mutex
+	w      io.Writer
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+
+		if err := p.writeLine(p.buf[:i]); err != nil {
+			return 0, err
+		}
+
+		p.buf = p.buf[i+1:]
 	}
-	args = append(args, extraArgs...)
+}
 
-	cmd := exec.CommandContext(
-		ctx,
-		"docker",
-		args...,
-	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// Flush writes whatever is left without a trailing new line.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		_ = p.writeLine(p.buf)
+		p.buf = nil
+	}
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run docker compose: %w", err)
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, line); err != nil {
+		return fmt.Errorf("failed to write log line: %w", err)
 	}
 
 	return nil
 }
 
-func (dc *DockerCompose) ApplyMetadata(ctx context.Context, endpoint string) error {
-	cmd := exec.CommandContext( //nolint:gosec
-		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"exec",
-		"console",
-		"hasura-cli",
-		"metadata", "apply",
-		"--endpoint", endpoint,
-		"--skip-update-check",
-	)
+func (dc *DockerCompose) Wrapper(ctx context.Context, extraArgs ...string) error {
+	cmd := dc.command(ctx, extraArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to run docker compose: %w", err)
@@ -163,111 +514,119 @@ func (dc *DockerCompose) ApplyMetadata(ctx context.Context, endpoint string) err
 	return nil
 }
 
-func (dc *DockerCompose) ReloadMetadata(ctx context.Context) error {
-	cmd := exec.CommandContext( //nolint:gosec
-		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"exec",
-		"console",
-		"hasura-cli",
-		"metadata", "reload",
-		"--endpoint", "http://graphql:8080",
-		"--skip-update-check",
-	)
+// Restart restarts the containers of the given services.
+func (dc *DockerCompose) Restart(ctx context.Context, services ...string) error {
+	if dc.api == nil {
+		return dc.Wrapper(ctx, append([]string{"restart"}, services...)...)
+	}
 
-	f, err := pty.Start(cmd)
+	containers, err := dc.containers(ctx, true, services...)
 	if err != nil {
-		return fmt.Errorf("failed to start pty: %w", err)
+		return fmt.Errorf("failed to list containers: %w", err)
 	}
-	defer f.Close()
 
-	if n, err := io.Copy(os.Stdout, f); err != nil {
-		var pathError *fs.PathError
-		switch {
-		case errors.As(err, &pathError) && n > 0 && pathError.Op == op:
-			// linux pty returns an error when the process exits
-			return nil
-		default:
-			return fmt.Errorf("failed to copy pty output: %w", err)
+	for _, c := range containers {
+		if err := dc.api.ContainerRestart(ctx, c.ID); err != nil {
+			return err //nolint:wrapcheck
 		}
 	}
 
 	return nil
 }
 
-func (dc *DockerCompose) ApplyMigrations(ctx context.Context, endpoint string) error {
-	cmd := exec.CommandContext( //nolint:gosec
-		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"exec",
-		"console",
-		"hasura-cli",
-		"migrate",
-		"apply",
-		"--endpoint", endpoint,
-		"--all-databases",
-		"--skip-update-check",
-	)
+// StopServices stops the containers of the given services without removing
+// them, Start brings them back.
+func (dc *DockerCompose) StopServices(ctx context.Context, services ...string) error {
+	if len(services) == 0 {
+		return nil
+	}
 
-	f, err := pty.Start(cmd)
-	if err != nil {
-		return fmt.Errorf("failed to start pty: %w", err)
+	cmd := dc.command(ctx, append([]string{"stop"}, services...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to stop services: %w", err)
 	}
-	defer f.Close()
 
-	if n, err := io.Copy(os.Stdout, f); err != nil {
-		var pathError *fs.PathError
-		switch {
-		case errors.As(err, &pathError) && n > 0 && pathError.Op == op:
-			// linux pty returns an error when the process exits
-			return nil
-		default:
-			return fmt.Errorf("failed to copy pty output: %w", err)
+	return nil
+}
+
+// Exec runs a command in a running service without a TTY, connecting stdin and
+// stdout to the given reader and writer. If the command fails the returned error
+// wraps a *dockerapi.ExitError or an *exec.ExitError with its exit code.
+func (dc *DockerCompose) Exec(
+	ctx context.Context,
+	stdin io.Reader,
+	stdout io.Writer,
+	service string,
+	command ...string,
+) error {
+	if dc.api == nil {
+		cmd := dc.command(ctx, append([]string{"exec", "-T", service}, command...)...)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to exec in %s: %w", service, err)
 		}
+
+		return nil
+	}
+
+	containers, err := dc.containers(ctx, false, service)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	if len(containers) == 0 {
+		return fmt.Errorf("service %s is not running", service) //nolint:err113
+	}
+
+	if err := dc.api.Exec(ctx, containers[0].ID, dockerapi.ExecOptions{
+		Cmd:    command,
+		Env:    nil,
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to exec in %s: %w", service, err)
 	}
 
 	return nil
 }
 
-func (dc *DockerCompose) ApplySeeds(ctx context.Context, endpoint string) error {
-	cmd := exec.CommandContext( //nolint:gosec
+func (dc *DockerCompose) hasuraCLI(
+	ctx context.Context, stdout io.Writer, args ...string,
+) error {
+	return dc.Exec(
 		ctx,
-		"docker", "compose",
-		"--project-directory", dc.workingDir,
-		"-f", dc.filepath,
-		"-p", dc.projectName,
-		"exec",
+		nil,
+		stdout,
 		"console",
-		"hasura-cli",
-		"seed",
-		"apply",
-		"--endpoint", endpoint,
-		"--all-databases",
-		"--skip-update-check",
+		append(append([]string{"hasura-cli"}, args...), "--skip-update-check")...,
 	)
+}
 
-	f, err := pty.Start(cmd)
-	if err != nil {
-		return fmt.Errorf("failed to start pty: %w", err)
-	}
-	defer f.Close()
+func (dc *DockerCompose) ApplyMetadata(ctx context.Context, endpoint string) error {
+	return dc.hasuraCLI(ctx, io.Discard, "metadata", "apply", "--endpoint", endpoint)
+}
 
-	if n, err := io.Copy(os.Stdout, f); err != nil {
-		var pathError *fs.PathError
-		switch {
-		case errors.As(err, &pathError) && n > 0 && pathError.Op == op:
-			// linux pty returns an error when the process exits
-			return nil
-		default:
-			return fmt.Errorf("failed to copy pty output: %w", err)
-		}
-	}
+func (dc *DockerCompose) ReloadMetadata(ctx context.Context) error {
+	return dc.hasuraCLI(
+		ctx, os.Stdout, "metadata", "reload", "--endpoint", "http://graphql:8080",
+	)
+}
 
-	return nil
+func (dc *DockerCompose) ApplyMigrations(ctx context.Context, endpoint string) error {
+	return dc.hasuraCLI(
+		ctx, os.Stdout, "migrate", "apply", "--endpoint", endpoint, "--all-databases",
+	)
+}
+
+func (dc *DockerCompose) ApplySeeds(ctx context.Context, endpoint string) error {
+	return dc.hasuraCLI(
+		ctx, os.Stdout, "seed", "apply", "--endpoint", endpoint, "--all-databases",
+	)
 }
@@ -0,0 +1,141 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nhost/cli/dockerapi"
+)
+
+func TestParseContainerStatus(t *testing.T) {
+	t.Parallel()
+
+	expected := []ContainerStatus{
+		{
+			Name:    "proj-graphql-1",
+			Service: "graphql",
+			State:   "running",
+			Health:  "healthy",
+			Image:   "nhost/graphql-engine:v2.36.0",
+			Publishers: []Publisher{
+				{URL: "", TargetPort: 8080, PublishedPort: 0, Protocol: "tcp"},
+			},
+		},
+		{
+			Name:    "proj-postgres-1",
+			Service: "postgres",
+			State:   "exited",
+			Health:  "",
+			Image:   "nhost/postgres:14",
+			Publishers: []Publisher{
+				{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 5432, Protocol: "tcp"},
+			},
+		},
+	}
+
+	//nolint:lll
+	cases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "json lines",
+			input: `{"Name":"proj-graphql-1","Service":"graphql","State":"running","Health":"healthy","Image":"nhost/graphql-engine:v2.36.0","Publishers":[{"URL":"","TargetPort":8080,"PublishedPort":0,"Protocol":"tcp"}]}
+{"Name":"proj-postgres-1","Service":"postgres","State":"exited","Health":"","Image":"nhost/postgres:14","Publishers":[{"URL":"0.0.0.0","TargetPort":5432,"PublishedPort":5432,"Protocol":"tcp"}]}
+`,
+		},
+		{
+			name:  "json array",
+			input: `[{"Name":"proj-graphql-1","Service":"graphql","State":"running","Health":"healthy","Image":"nhost/graphql-engine:v2.36.0","Publishers":[{"URL":"","TargetPort":8080,"PublishedPort":0,"Protocol":"tcp"}]},{"Name":"proj-postgres-1","Service":"postgres","State":"exited","Health":"","Image":"nhost/postgres:14","Publishers":[{"URL":"0.0.0.0","TargetPort":5432,"PublishedPort":5432,"Protocol":"tcp"}]}]`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseContainerStatus([]byte(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestIngressNames(t *testing.T) {
+	t.Parallel()
+
+	svc, err := graphql(getConfig(), "dev", false, 1337, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"graphql", "hasura"}, svc.IngressNames()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		out bytes.Buffer
+		mu  sync.Mutex
+	)
+
+	auth := &prefixWriter{prefix: "app-auth-1  | ", mu: &mu, w: &out, buf: nil}
+	storage := &prefixWriter{prefix: "app-storage-1  | ", mu: &mu, w: &out, buf: nil}
+
+	_, _ = auth.Write([]byte("2024-01-01T00:00:00Z starting"))
+	_, _ = storage.Write([]byte("2024-01-01T00:00:01Z ready\n"))
+	_, _ = auth.Write([]byte(" server\n2024-01-01T00:00:02Z listening"))
+	auth.Flush()
+
+	expected := "app-storage-1  | 2024-01-01T00:00:01Z ready\n" +
+		"app-auth-1  | 2024-01-01T00:00:00Z starting server\n" +
+		"app-auth-1  | 2024-01-01T00:00:02Z listening\n"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	if got := ParseLogLine(strings.Split(out.String(), "\n")[1], "app"); got.Service != "auth" {
+		t.Errorf("expected lines to be parsed as auth logs, got %q", got.Service)
+	}
+}
+
+func TestContainerStatus(t *testing.T) {
+	t.Parallel()
+
+	got := containerStatus(dockerapi.Container{
+		ID:     "abc",
+		Names:  []string{"/app-postgres-1"},
+		Image:  "nhost/postgres:16",
+		State:  "running",
+		Status: "Up 5 minutes (healthy)",
+		Labels: map[string]string{labelComposeService: "postgres"},
+		Ports: []dockerapi.Port{
+			{IP: "0.0.0.0", PrivatePort: 5432, PublicPort: 5432, Type: "tcp"},
+		},
+	}, "healthy")
+
+	expected := ContainerStatus{
+		Name:    "app-postgres-1",
+		Service: "postgres",
+		State:   "running",
+		Health:  "healthy",
+		Image:   "nhost/postgres:16",
+		Publishers: []Publisher{
+			{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 5432, Protocol: "tcp"},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -83,6 +83,8 @@ func graphql( //nolint:funlen
 		Restart:    "always",
 		Volumes:    nil,
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}, nil
 }
 
@@ -136,10 +138,7 @@ func console( //nolint:funlen
 	}
 
 	return &Service{
-		Image: fmt.Sprintf(
-			"nhost/graphql-engine:%s.cli-migrations-v3",
-			*cfg.GetHasura().GetVersion(),
-		),
+		Image: HasuraCLIImage(*cfg.GetHasura().GetVersion()),
 		Command: []string{
 			"bash", "-c",
 			fmt.Sprintf(`
@@ -196,5 +195,7 @@ func console( //nolint:funlen
 			},
 		},
 		WorkingDir: ptr("/app"),
+		Deploy:     nil,
+		Networks:   nil,
 	}, nil
 }
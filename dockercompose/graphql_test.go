@@ -101,6 +101,8 @@ func expectedGraphql() *Service {
 		Restart:    "always",
 		Volumes:    nil,
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -239,6 +241,8 @@ func expectedConsole() *Service {
 			{Type: "bind", Source: "/path/to/nhost", Target: "/app", ReadOnly: ptr(false)},
 		},
 		WorkingDir: ptr("/app"),
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
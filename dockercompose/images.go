@@ -0,0 +1,100 @@
+package dockercompose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+)
+
+// HasuraCLIImage is the image used to run hasura-cli outside of the environment.
+func HasuraCLIImage(hasuraVersion string) string {
+	return fmt.Sprintf("nhost/graphql-engine:%s.cli-migrations-v3", hasuraVersion)
+}
+
+// Images returns the images used by the services, sorted and without duplicates.
+func (c *ComposeFile) Images() []string {
+	images := make([]string, 0, len(c.Services))
+	for _, svc := range c.Services {
+		if svc.Image != "" && !slices.Contains(images, svc.Image) {
+			images = append(images, svc.Image)
+		}
+	}
+
+	slices.Sort(images)
+
+	return images
+}
+
+func (d *Docker) imageCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext( //nolint:gosec
+		ctx, d.runtime.Binary(), append([]string{"image"}, args...)...,
+	)
+}
+
+// MissingImages returns the images that aren't available locally.
+func (d *Docker) MissingImages(ctx context.Context, images []string) ([]string, error) {
+	missing := make([]string, 0)
+
+	for _, image := range images {
+		cmd := d.imageCommand(ctx, "inspect", image)
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+			}
+
+			missing = append(missing, image)
+		}
+	}
+
+	return missing, nil
+}
+
+// PullImages pulls the images from their registries.
+func (d *Docker) PullImages(
+	ctx context.Context, stdout, stderr io.Writer, images []string,
+) error {
+	for _, image := range images {
+		cmd := d.imageCommand(ctx, "pull", image)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", image, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveImages writes the images to a tar archive that can be loaded with
+// LoadImages on a machine without access to the registries.
+func (d *Docker) SaveImages(
+	ctx context.Context, stdout, stderr io.Writer, path string, images []string,
+) error {
+	cmd := d.imageCommand(ctx, append([]string{"save", "-o", path}, images...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+
+	return nil
+}
+
+// LoadImages loads the images in a tar archive written by SaveImages.
+func (d *Docker) LoadImages(ctx context.Context, stdout, stderr io.Writer, path string) error {
+	cmd := d.imageCommand(ctx, "load", "-i", path)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to load images: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComposeFileImages(t *testing.T) {
+	t.Parallel()
+
+	composeFile := &ComposeFile{
+		Services: map[string]*Service{
+			"graphql": {Image: "nhost/graphql-engine:v2.36.0"},             //nolint:exhaustruct
+			"console": {Image: HasuraCLIImage("v2.36.0")},                  //nolint:exhaustruct
+			"sms":     {Image: "nhost/cli:v1.0.0"},                         //nolint:exhaustruct
+			"config":  {Image: "nhost/cli:v1.0.0"},                         //nolint:exhaustruct
+			"minio":   {Image: "minio/minio:RELEASE.2025-02-28T09-55-16Z"}, //nolint:exhaustruct
+			"build":   {Image: ""},                                         //nolint:exhaustruct
+		},
+		Volumes:  nil,
+		Networks: nil,
+	}
+
+	expected := []string{
+		"minio/minio:RELEASE.2025-02-28T09-55-16Z",
+		"nhost/cli:v1.0.0",
+		"nhost/graphql-engine:v2.36.0",
+		"nhost/graphql-engine:v2.36.0.cli-migrations-v3",
+	}
+	if diff := cmp.Diff(expected, composeFile.Images()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+type binaryRuntime struct {
+	NerdctlRuntime
+
+	binary string
+}
+
+func (r *binaryRuntime) Binary() string {
+	return r.binary
+}
+
+func TestMissingImages(t *testing.T) {
+	t.Parallel()
+
+	// fake runtime that only knows about the postgres image
+	binary := filepath.Join(t.TempDir(), "runtime")
+	if err := os.WriteFile(
+		binary,
+		[]byte("#!/bin/sh\n[ \"$1 $2 $3\" = \"image inspect postgres:16\" ]\n"),
+		0o755, //nolint:gosec,mnd
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := NewDocker(&binaryRuntime{NerdctlRuntime{}, binary})
+
+	missing, err := docker.MissingImages(
+		context.Background(), []string{"nhost/cli:v1.0.0", "postgres:16", "traefik:v3.1"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"nhost/cli:v1.0.0", "traefik:v3.1"}, missing); diff != "" {
+		t.Error(diff)
+	}
+
+	docker = NewDocker(&binaryRuntime{NerdctlRuntime{}, filepath.Join(t.TempDir(), "missing")})
+	if _, err := docker.MissingImages(context.Background(), []string{"postgres:16"}); err == nil {
+		t.Error("expected error when the runtime isn't available")
+	}
+}
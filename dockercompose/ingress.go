@@ -1,8 +1,12 @@
 package dockercompose
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 type Ingresses []Ingress
@@ -54,3 +58,149 @@ func (i Ingress) Labels() map[string]string {
 
 	return labels
 }
+
+var hostRuleRe = regexp.MustCompile("Host\\(`local\\.([^`]+)\\.nhost\\.run`\\)")
+
+var hostRegexpNameRe = regexp.MustCompile(
+	"HostRegexp\\(`\\^[^`]*?\\\\\\.([a-z0-9-]+)\\\\\\.local\\\\\\.nhost\\\\\\.run\\$`\\)",
+)
+
+// IngressNames returns the names the service is reachable at through traefik, for
+// instance "auth" for <subdomain>.auth.local.nhost.run.
+func (s *Service) IngressNames() []string {
+	names := make([]string, 0)
+
+	for k, v := range s.Labels {
+		if !strings.HasPrefix(k, "traefik.http.routers.") || !strings.HasSuffix(k, ".rule") {
+			continue
+		}
+
+		matches := hostRuleRe.FindAllStringSubmatch(v, -1)
+		// routes of the shared ingress only have the subdomain specific host
+		matches = append(matches, hostRegexpNameRe.FindAllStringSubmatch(v, -1)...)
+
+		for _, match := range matches {
+			if !slices.Contains(names, match[1]) {
+				names = append(names, match[1])
+			}
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+var hostRegexpRuleRe = regexp.MustCompile(
+	"HostRegexp\\(`\\^\\.\\+\\\\\\.[a-z0-9-]+\\\\\\.([^`]+)\\$`\\)",
+)
+
+// LocalDomain returns the domain the environment is served at.
+func (c *ComposeFile) LocalDomain() string {
+	for _, svc := range c.Services {
+		for k, v := range svc.Labels {
+			if !strings.HasPrefix(k, "traefik.http.routers.") || !strings.HasSuffix(k, ".rule") {
+				continue
+			}
+
+			if match := hostRegexpRuleRe.FindStringSubmatch(v); match != nil {
+				return strings.ReplaceAll(match[1], "\\", "")
+			}
+		}
+	}
+
+	return DefaultLocalDomain
+}
+
+// Entrypoint returns the port traefik listens on and whether TLS is enabled.
+func (c *ComposeFile) Entrypoint() (uint, bool, error) {
+	// with the shared ingress the port is in the labels of the services
+	port, ok := c.sharedIngressPort()
+
+	if traefik, found := c.Services["traefik"]; found && len(traefik.Ports) > 0 {
+		p, err := strconv.ParseUint(traefik.Ports[0].Published, 10, 32)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to parse traefik port: %w", err)
+		}
+
+		port, ok = uint(p), true
+	}
+
+	if !ok {
+		return 0, false, errors.New("traefik service not found") //nolint:err113
+	}
+
+	for _, svc := range c.Services {
+		for k, v := range svc.Labels {
+			if strings.HasPrefix(k, "traefik.http.routers.") &&
+				strings.HasSuffix(k, ".tls") && v == "true" {
+				return port, true, nil
+			}
+		}
+	}
+
+	return port, false, nil
+}
+
+// FileRoutes converts the traefik labels of the services into the format of
+// traefik's file provider. It is used with runtimes that don't provide a docker
+// compatible API traefik can discover the labels from.
+func FileRoutes(services map[string]*Service) map[string]any {
+	routers := map[string]map[string]any{}
+	backends := map[string]any{}
+	middlewares := map[string]any{}
+	rewrites := map[string]map[string]string{}
+
+	for svcName, svc := range services {
+		for k, v := range svc.Labels {
+			kind, rest, _ := strings.Cut(strings.TrimPrefix(k, "traefik.http."), ".")
+			name, field, _ := strings.Cut(rest, ".")
+
+			switch {
+			case kind == "routers":
+				if routers[name] == nil {
+					routers[name] = map[string]any{}
+				}
+
+				switch field {
+				case "entrypoints":
+					routers[name]["entryPoints"] = strings.Split(v, ",")
+				case "middlewares":
+					routers[name]["middlewares"] = strings.Split(v, ",")
+				case "tls":
+					if v == "true" {
+						routers[name]["tls"] = map[string]any{}
+					}
+				default:
+					routers[name][field] = v
+				}
+			case kind == "services" && field == "loadbalancer.server.port":
+				backends[name] = map[string]any{
+					"loadBalancer": map[string]any{
+						"servers": []map[string]string{
+							{"url": fmt.Sprintf("http://%s:%s", svcName, v)},
+						},
+					},
+				}
+			case kind == "middlewares" && strings.HasPrefix(field, "replacepathregex."):
+				if rewrites[name] == nil {
+					rewrites[name] = map[string]string{}
+				}
+
+				rewrites[name][strings.TrimPrefix(field, "replacepathregex.")] = v
+			}
+		}
+	}
+
+	for name, rewrite := range rewrites {
+		middlewares[name] = map[string]any{"replacePathRegex": rewrite}
+	}
+
+	return map[string]any{
+		"http": map[string]any{
+			"routers":     routers,
+			"services":    backends,
+			"middlewares": middlewares,
+		},
+	}
+}
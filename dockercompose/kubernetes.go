@@ -0,0 +1,718 @@
+package dockercompose
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigMaps can't be larger than 1MiB, bind mounts that don't fit are
+	// exported as empty volumes.
+	kubernetesConfigMapLimit = 1 << 20
+	kubernetesVolumeSize     = "1Gi"
+)
+
+var errConfigMapTooLarge = errors.New("larger than a ConfigMap can hold")
+
+// KubernetesManifests are the objects equivalent to a compose file and the
+// parts of it that couldn't be converted.
+type KubernetesManifests struct {
+	Objects  []map[string]any
+	Warnings []string
+}
+
+// YAML returns the objects as a multi-document YAML file preceded by the
+// warnings as comments.
+func (m *KubernetesManifests) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, warning := range m.Warnings {
+		buf.WriteString("# WARNING: " + warning + "\n")
+	}
+
+	for i, obj := range m.Objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", obj["kind"], err)
+		}
+
+		buf.Write(b)
+	}
+
+	return buf.Bytes(), nil
+}
+
+type kubernetesExport struct {
+	namespace string
+	manifests *KubernetesManifests
+}
+
+func (k *kubernetesExport) warn(format string, args ...any) {
+	k.manifests.Warnings = append(k.manifests.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (k *kubernetesExport) add(apiVersion, kind, name string, obj map[string]any) {
+	metadata := map[string]any{
+		"name": name,
+		"labels": map[string]string{
+			"app.kubernetes.io/part-of": "nhost",
+		},
+	}
+	if k.namespace != "" {
+		metadata["namespace"] = k.namespace
+	}
+
+	obj["apiVersion"] = apiVersion
+	obj["kind"] = kind
+	obj["metadata"] = metadata
+
+	k.manifests.Objects = append(k.manifests.Objects, obj)
+}
+
+//nolint:gochecknoglobals
+var kubernetesNameRe = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// kubernetesName turns a compose name into a valid object name.
+func kubernetesName(name string) string {
+	return strings.Trim(kubernetesNameRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+//nolint:gochecknoglobals
+var configMapKeyRe = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// Kubernetes converts the compose file into Deployments, Services, Secrets,
+// ConfigMaps, PersistentVolumeClaims and Ingresses. Traefik isn't exported,
+// its routes become Ingresses for the cluster's ingress controller instead.
+func (c *ComposeFile) Kubernetes(namespace string) (*KubernetesManifests, error) {
+	k := &kubernetesExport{
+		namespace: namespace,
+		manifests: &KubernetesManifests{
+			Objects:  make([]map[string]any, 0),
+			Warnings: make([]string, 0),
+		},
+	}
+
+	volumes := make([]string, 0, len(c.Volumes))
+	for name := range c.Volumes {
+		volumes = append(volumes, name)
+	}
+
+	slices.Sort(volumes)
+
+	for _, name := range volumes {
+		k.add("v1", "PersistentVolumeClaim", kubernetesName(name), map[string]any{
+			"spec": map[string]any{
+				"accessModes": []string{"ReadWriteOnce"},
+				"resources": map[string]any{
+					"requests": map[string]string{"storage": kubernetesVolumeSize},
+				},
+			},
+		})
+	}
+
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		if name != "traefik" {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	ports := c.servicePorts()
+
+	extraHosts := false
+
+	for _, name := range names {
+		if err := k.service(name, c.Services[name], ports[name]); err != nil {
+			return nil, err
+		}
+
+		extraHosts = extraHosts || len(c.Services[name].ExtraHosts) > 0
+	}
+
+	if extraHosts {
+		k.warn(
+			"extra hosts aren't exported, the environment's hostnames must resolve to the ingress controller",
+		)
+	}
+
+	return k.manifests, nil
+}
+
+// servicePorts returns the ports the services listen on. Compose doesn't need
+// them declared so they are collected from the published ports, the traefik
+// routes and the addresses the services use to reach each other.
+func (c *ComposeFile) servicePorts() map[string][]uint {
+	ports := make(map[string][]uint, len(c.Services))
+	addPort := func(name string, port uint) {
+		if port != 0 && !slices.Contains(ports[name], port) {
+			ports[name] = append(ports[name], port)
+		}
+	}
+
+	references := make(map[string]*regexp.Regexp, len(c.Services))
+	for name := range c.Services {
+		references[name] = regexp.MustCompile(`(?:^|[^-.a-zA-Z0-9])` + regexp.QuoteMeta(name) + `:(\d+)`)
+	}
+
+	for name, svc := range c.Services {
+		for _, p := range svc.Ports {
+			addPort(name, p.Target)
+		}
+
+		for k, v := range svc.Labels {
+			if strings.HasPrefix(k, "traefik.http.services.") &&
+				strings.HasSuffix(k, ".loadbalancer.server.port") {
+				port, _ := strconv.ParseUint(v, 10, 32)
+				addPort(name, uint(port))
+			}
+		}
+
+		values := slices.Concat(svc.Command, svc.EntryPoint)
+		for _, v := range svc.Environment {
+			values = append(values, v)
+		}
+
+		for target, re := range references {
+			for _, v := range values {
+				for _, match := range re.FindAllStringSubmatch(v, -1) {
+					port, _ := strconv.ParseUint(match[1], 10, 32)
+					addPort(target, uint(port))
+				}
+			}
+		}
+	}
+
+	for name := range ports {
+		slices.Sort(ports[name])
+	}
+
+	return ports
+}
+
+func (k *kubernetesExport) service(name string, svc *Service, ports []uint) error {
+	objName := kubernetesName(name)
+	selector := map[string]string{"app.kubernetes.io/name": objName}
+
+	container := map[string]any{
+		"name":  objName,
+		"image": svc.Image,
+	}
+
+	if len(svc.EntryPoint) > 0 {
+		container["command"] = svc.EntryPoint
+	}
+
+	if len(svc.Command) > 0 {
+		container["args"] = svc.Command
+	}
+
+	if svc.WorkingDir != nil {
+		container["workingDir"] = *svc.WorkingDir
+	}
+
+	if len(svc.Environment) > 0 {
+		// environments hold secrets like the admin secret or the JWT key
+		k.add("v1", "Secret", objName+"-env", map[string]any{
+			"type":       "Opaque",
+			"stringData": svc.Environment,
+		})
+
+		container["envFrom"] = []map[string]any{
+			{"secretRef": map[string]string{"name": objName + "-env"}},
+		}
+	}
+
+	if len(ports) > 0 {
+		containerPorts := make([]map[string]any, 0, len(ports))
+		for _, p := range ports {
+			containerPorts = append(containerPorts, map[string]any{"containerPort": p})
+		}
+
+		container["ports"] = containerPorts
+	}
+
+	if probe := kubernetesProbe(svc.HealthCheck); probe != nil {
+		container["readinessProbe"] = probe
+	}
+
+	if svc.Deploy != nil && svc.Deploy.Resources != nil {
+		container["resources"] = kubernetesResources(svc.Deploy.Resources)
+	}
+
+	volumes, mounts, persistent, err := k.volumes(objName, svc.Volumes)
+	if err != nil {
+		return err
+	}
+
+	if len(mounts) > 0 {
+		container["volumeMounts"] = mounts
+	}
+
+	podSpec := map[string]any{"containers": []map[string]any{container}}
+	if len(volumes) > 0 {
+		podSpec["volumes"] = volumes
+	}
+
+	replicas := 1
+	if svc.Deploy != nil && svc.Deploy.Replicas > 0 {
+		replicas = int(svc.Deploy.Replicas)
+	}
+
+	spec := map[string]any{
+		"replicas": replicas,
+		"selector": map[string]any{"matchLabels": selector},
+		"template": map[string]any{
+			"metadata": map[string]any{"labels": selector},
+			"spec":     podSpec,
+		},
+	}
+
+	// ReadWriteOnce volumes can't be attached to the old and new pods at once
+	if persistent {
+		spec["strategy"] = map[string]string{"type": "Recreate"}
+	}
+
+	k.add("apps/v1", "Deployment", objName, map[string]any{"spec": spec})
+
+	if len(ports) == 0 {
+		return nil
+	}
+
+	servicePorts := make([]map[string]any, 0, len(ports))
+	for _, p := range ports {
+		servicePorts = append(servicePorts, map[string]any{
+			"name":       "port-" + strconv.FormatUint(uint64(p), 10),
+			"port":       p,
+			"targetPort": p,
+		})
+	}
+
+	serviceNames := []string{objName}
+	for _, network := range svc.Networks {
+		if network != nil {
+			for _, alias := range network.Aliases {
+				serviceNames = append(serviceNames, kubernetesName(alias))
+			}
+		}
+	}
+
+	for _, serviceName := range serviceNames {
+		k.add("v1", "Service", serviceName, map[string]any{
+			"spec": map[string]any{
+				"selector": selector,
+				"ports":    servicePorts,
+			},
+		})
+	}
+
+	k.ingress(name, objName, svc.Labels)
+
+	return nil
+}
+
+func kubernetesSeconds(d string) int {
+	duration, err := time.ParseDuration(d)
+	if err != nil {
+		return 0
+	}
+
+	return int(duration.Seconds())
+}
+
+func kubernetesProbe(hc *HealthCheck) map[string]any {
+	if hc == nil || len(hc.Test) < 2 { //nolint:mnd
+		return nil
+	}
+
+	var command []string
+
+	switch hc.Test[0] {
+	case "CMD":
+		command = hc.Test[1:]
+	case "CMD-SHELL":
+		command = []string{"sh", "-c", strings.Join(hc.Test[1:], " ")}
+	default:
+		return nil
+	}
+
+	probe := map[string]any{
+		"exec": map[string]any{"command": command},
+	}
+
+	if s := kubernetesSeconds(hc.Interval); s > 0 {
+		probe["periodSeconds"] = s
+	}
+
+	if s := kubernetesSeconds(hc.Timeout); s > 0 {
+		probe["timeoutSeconds"] = s
+	}
+
+	if s := kubernetesSeconds(hc.StartPeriod); s > 0 {
+		probe["initialDelaySeconds"] = s
+	}
+
+	return probe
+}
+
+// kubernetesQuantity converts compose's binary units, i.e. 512M, to the
+// kubernetes ones.
+func kubernetesQuantity(memory string) string {
+	if strings.HasSuffix(memory, "K") || strings.HasSuffix(memory, "M") ||
+		strings.HasSuffix(memory, "G") {
+		return memory + "i"
+	}
+
+	return memory
+}
+
+func kubernetesResources(resources *Resources) map[string]any {
+	convert := func(limits *ResourceLimits) map[string]string {
+		r := map[string]string{}
+		if limits.CPUs != "" {
+			r["cpu"] = limits.CPUs
+		}
+
+		if limits.Memory != "" {
+			r["memory"] = kubernetesQuantity(limits.Memory)
+		}
+
+		return r
+	}
+
+	r := map[string]any{}
+	if resources.Limits != nil {
+		r["limits"] = convert(resources.Limits)
+	}
+
+	if resources.Reservations != nil {
+		r["requests"] = convert(resources.Reservations)
+	}
+
+	return r
+}
+
+// volumes converts the named volumes to claims and the bind mounts to
+// ConfigMaps with the content of the files as they are now.
+func (k *kubernetesExport) volumes(
+	objName string, volumes []Volume,
+) ([]map[string]any, []map[string]any, bool, error) {
+	podVolumes := make([]map[string]any, 0, len(volumes))
+	mounts := make([]map[string]any, 0, len(volumes))
+	persistent := false
+
+	for i, v := range volumes {
+		volumeName := fmt.Sprintf("volume-%d", i)
+		mount := map[string]any{
+			"name":      volumeName,
+			"mountPath": v.Target,
+		}
+
+		if v.ReadOnly != nil && *v.ReadOnly {
+			mount["readOnly"] = true
+		}
+
+		switch v.Type {
+		case "volume":
+			persistent = true
+
+			podVolumes = append(podVolumes, map[string]any{
+				"name": volumeName,
+				"persistentVolumeClaim": map[string]string{
+					"claimName": kubernetesName(v.Source),
+				},
+			})
+		case "bind":
+			source, isFile, err := k.configMap(fmt.Sprintf("%s-files-%d", objName, i), v.Source)
+			if err != nil {
+				return nil, nil, false, err
+			}
+
+			if isFile {
+				mount["subPath"] = filepath.Base(v.Source)
+			}
+
+			podVolumes = append(podVolumes, map[string]any{"name": volumeName})
+			for key, value := range source {
+				podVolumes[len(podVolumes)-1][key] = value
+			}
+		default:
+			k.warn("%s: volume %s of type %s isn't supported", objName, v.Target, v.Type)
+			continue
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return podVolumes, mounts, persistent, nil
+}
+
+//nolint:gochecknoglobals
+var configMapSkipped = []string{".git", "node_modules", ".secrets"}
+
+// configMap adds a ConfigMap with the files at path and returns the volume
+// source to mount it. Sockets and folders that don't fit in a ConfigMap are
+// replaced by empty folders. Secrets, git and node_modules are skipped, they
+// don't belong in a ConfigMap.
+func (k *kubernetesExport) configMap(name, path string) (map[string]any, bool, error) { //nolint:cyclop,funlen
+	emptyDir := map[string]any{"emptyDir": map[string]any{}}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		k.warn("%s: %s can't be read, it is exported as an empty folder", name, path)
+		return emptyDir, false, nil //nolint:nilerr
+	}
+
+	tooLarge := func() (map[string]any, bool, error) {
+		k.warn(
+			"%s: %s is larger than a ConfigMap can hold, it is exported as an empty folder",
+			name, path,
+		)
+
+		return emptyDir, false, nil
+	}
+
+	files := map[string]string{}
+
+	switch {
+	case info.Mode().IsRegular():
+		if slices.Contains(configMapSkipped, filepath.Base(path)) {
+			k.warn("%s: %s is exported as an empty folder to keep it out of the ConfigMap", name, path)
+			return emptyDir, false, nil
+		}
+
+		if info.Size() > kubernetesConfigMapLimit {
+			return tooLarge()
+		}
+
+		files[filepath.Base(path)] = path
+	case info.IsDir():
+		size := int64(0)
+		if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if p != path && slices.Contains(configMapSkipped, d.Name()) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			// stop before reading a whole repository into memory
+			size += info.Size()
+			if size > kubernetesConfigMapLimit {
+				return errConfigMapTooLarge
+			}
+
+			rel, _ := filepath.Rel(path, p)
+			files[filepath.ToSlash(rel)] = p
+
+			return nil
+		}); errors.Is(err, errConfigMapTooLarge) {
+			return tooLarge()
+		} else if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	default:
+		k.warn("%s: %s isn't a file or folder, it is exported as an empty folder", name, path)
+		return emptyDir, false, nil
+	}
+
+	data := map[string]string{}
+	binaryData := map[string]string{}
+	items := make([]map[string]string, 0, len(files))
+
+	for rel, p := range files {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		key := configMapKeyRe.ReplaceAllString(rel, "_")
+
+		if utf8.Valid(b) {
+			data[key] = string(b)
+		} else {
+			binaryData[key] = base64.StdEncoding.EncodeToString(b)
+		}
+
+		items = append(items, map[string]string{"key": key, "path": rel})
+	}
+
+	slices.SortFunc(items, func(a, b map[string]string) int {
+		return strings.Compare(a["key"], b["key"])
+	})
+
+	obj := map[string]any{"data": data}
+	if len(binaryData) > 0 {
+		obj["binaryData"] = binaryData
+	}
+
+	k.add("v1", "ConfigMap", name, obj)
+
+	return map[string]any{
+		"configMap": map[string]any{"name": name, "items": items},
+	}, info.Mode().IsRegular(), nil
+}
+
+//nolint:gochecknoglobals
+var (
+	ruleHostRe       = regexp.MustCompile("Host\\(`([^`]+)`\\)")
+	ruleHostRegexpRe = regexp.MustCompile("HostRegexp\\(`\\^?([^`]+?)\\$?`\\)")
+	rulePathRe       = regexp.MustCompile("PathPrefix\\(`([^`]+)`\\)")
+)
+
+// kubernetesRule returns the hosts and the path prefix of a traefik rule. Host
+// regexps are only supported as far as traefikHostMatch uses them, i.e. a
+// wildcard on the first label.
+func kubernetesRule(rule string) ([]string, string) {
+	hosts := make([]string, 0)
+
+	for _, match := range ruleHostRegexpRe.FindAllStringSubmatch(rule, -1) {
+		host := match[1]
+		if h, ok := strings.CutPrefix(host, ".+"); ok {
+			host = "*" + h
+		}
+
+		hosts = append(hosts, strings.ReplaceAll(host, "\\", ""))
+	}
+
+	for _, match := range ruleHostRe.FindAllStringSubmatch(rule, -1) {
+		hosts = append(hosts, match[1])
+	}
+
+	path := "/"
+	if match := rulePathRe.FindStringSubmatch(rule); match != nil {
+		path = match[1]
+	}
+
+	return hosts, path
+}
+
+// ingress converts the traefik routes of the service into an Ingress.
+func (k *kubernetesExport) ingress(name, objName string, labels map[string]string) {
+	type router struct {
+		rule    string
+		service string
+		tls     bool
+	}
+
+	routers := map[string]*router{}
+	backends := map[string]uint{}
+
+	for key, v := range labels {
+		kind, rest, _ := strings.Cut(strings.TrimPrefix(key, "traefik.http."), ".")
+		routerName, field, _ := strings.Cut(rest, ".")
+
+		switch {
+		case kind == "routers":
+			if routers[routerName] == nil {
+				routers[routerName] = &router{rule: "", service: "", tls: false}
+			}
+
+			switch field {
+			case "rule":
+				routers[routerName].rule = v
+			case "service":
+				routers[routerName].service = v
+			case "tls":
+				routers[routerName].tls = v == "true"
+			case "middlewares":
+				k.warn(
+					"%s: path rewrite of route %s isn't exported, configure it in the ingress controller",
+					name, routerName,
+				)
+			}
+		case kind == "services" && field == "loadbalancer.server.port":
+			port, _ := strconv.ParseUint(v, 10, 32)
+			backends[routerName] = uint(port)
+		}
+	}
+
+	if len(routers) == 0 {
+		return
+	}
+
+	paths := map[string][]map[string]any{}
+	tlsHosts := make([]string, 0)
+
+	for _, r := range routers {
+		hosts, path := kubernetesRule(r.rule)
+		for _, host := range hosts {
+			if !slices.ContainsFunc(paths[host], func(p map[string]any) bool { return p["path"] == path }) {
+				paths[host] = append(paths[host], map[string]any{
+					"path":     path,
+					"pathType": "Prefix",
+					"backend": map[string]any{
+						"service": map[string]any{
+							"name": objName,
+							"port": map[string]any{"number": backends[r.service]},
+						},
+					},
+				})
+			}
+
+			if r.tls && !slices.Contains(tlsHosts, host) {
+				tlsHosts = append(tlsHosts, host)
+			}
+		}
+	}
+
+	hosts := make([]string, 0, len(paths))
+	for host := range paths {
+		hosts = append(hosts, host)
+	}
+
+	slices.Sort(hosts)
+
+	rules := make([]map[string]any, 0, len(hosts))
+	for _, host := range hosts {
+		slices.SortFunc(paths[host], func(a, b map[string]any) int {
+			return strings.Compare(a["path"].(string), b["path"].(string)) //nolint:forcetypeassert
+		})
+
+		rules = append(rules, map[string]any{
+			"host": host,
+			"http": map[string]any{"paths": paths[host]},
+		})
+	}
+
+	spec := map[string]any{"rules": rules}
+
+	if len(tlsHosts) > 0 {
+		slices.Sort(tlsHosts)
+		spec["tls"] = []map[string]any{{"hosts": tlsHosts}}
+	}
+
+	k.add("networking.k8s.io/v1", "Ingress", objName, map[string]any{"spec": spec})
+}
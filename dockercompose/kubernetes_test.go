@@ -0,0 +1,336 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKubernetesRule(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		rule          string
+		expectedHosts []string
+		expectedPath  string
+	}{
+		{
+			name:          "host match",
+			rule:          traefikHostMatch("hasura"),
+			expectedHosts: []string{"*.hasura.local.nhost.run", "local.hasura.nhost.run"},
+			expectedPath:  "/",
+		},
+		{
+			name:          "path prefix",
+			rule:          traefikHostMatch("graphql") + "&& PathPrefix(`/v1`)",
+			expectedHosts: []string{"*.graphql.local.nhost.run", "local.graphql.nhost.run"},
+			expectedPath:  "/v1",
+		},
+		{
+			name:          "shared ingress",
+			rule:          sharedIngressRule(traefikHostMatch("auth"), "myapp"),
+			expectedHosts: []string{"myapp.auth.local.nhost.run"},
+			expectedPath:  "/",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hosts, path := kubernetesRule(tc.rule)
+			if diff := cmp.Diff(tc.expectedHosts, hosts); diff != "" {
+				t.Error(diff)
+			}
+
+			if path != tc.expectedPath {
+				t.Errorf("expected path %s, got %s", tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func TestComposeFileKubernetes(t *testing.T) { //nolint:maintidx
+	t.Parallel()
+
+	dataFolder := t.TempDir()
+	if err := os.WriteFile(
+		filepath.Join(dataFolder, "pg_hba_local.conf"), []byte("local all all trust\n"), 0o600,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	composeFile := &ComposeFile{
+		Services: map[string]*Service{
+			"traefik": {Image: "traefik:v3.1", Ports: ports(443, 443)}, //nolint:exhaustruct
+			"postgres": { //nolint:exhaustruct
+				Image:       "nhost/postgres:16",
+				Environment: map[string]string{"POSTGRES_PASSWORD": "postgres"},
+				HealthCheck: &HealthCheck{
+					Test:        []string{"CMD-SHELL", "pg_isready -U postgres", "-q"},
+					Timeout:     "60s",
+					Interval:    "5s",
+					StartPeriod: "60s",
+				},
+				Ports: ports(5432, 5432),
+				Volumes: []Volume{
+					{Type: "volume", Source: "db_main", Target: "/var/lib/postgresql/data", ReadOnly: nil},
+					{
+						Type:     "bind",
+						Source:   filepath.Join(dataFolder, "pg_hba_local.conf"),
+						Target:   "/etc/pg_hba_local.conf",
+						ReadOnly: ptr(true),
+					},
+				},
+			},
+			"graphql": { //nolint:exhaustruct
+				Image: "nhost/graphql-engine:v2.36.0",
+				Environment: map[string]string{
+					"HASURA_GRAPHQL_DATABASE_URL": "postgres://postgres@postgres:5432/local",
+				},
+				Labels: Ingresses{
+					{
+						Name:    "graphql",
+						TLS:     true,
+						Rule:    traefikHostMatch("graphql") + "&& PathPrefix(`/v1`)",
+						Port:    8080, //nolint:mnd
+						Rewrite: nil,
+					},
+				}.Labels(),
+				Deploy: &Deploy{
+					Replicas: 2, //nolint:mnd
+					Resources: &Resources{
+						Limits:       &ResourceLimits{CPUs: "0.5", Memory: "512M"},
+						Reservations: nil,
+					},
+				},
+			},
+			"sms": { //nolint:exhaustruct
+				Image:    "nhost/cli:v1.0.0",
+				Command:  []string{"sms", "--port", "4567"},
+				Ports:    []Port{{Mode: "ingress", Target: 4567, Published: "", Protocol: "tcp"}},
+				Networks: map[string]*Network{"default": {Aliases: []string{"api.twilio.com"}}},
+			},
+			"docs": { //nolint:exhaustruct
+				Image: "nginx",
+				Volumes: []Volume{
+					{Type: "bind", Source: filepath.Join(dataFolder, "missing"), Target: "/docs", ReadOnly: nil},
+				},
+			},
+		},
+		Volumes:  map[string]struct{}{"db_main": {}},
+		Networks: nil,
+	}
+
+	manifests, err := composeFile.Kubernetes("preview")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(manifests.Objects))
+	objects := map[string]map[string]any{}
+
+	for _, obj := range manifests.Objects {
+		name := obj["metadata"].(map[string]any)["name"].(string) //nolint:forcetypeassert
+
+		got = append(got, obj["kind"].(string)+"/"+name) //nolint:forcetypeassert
+		objects[obj["kind"].(string)+"/"+name] = obj     //nolint:forcetypeassert
+
+		if ns := obj["metadata"].(map[string]any)["namespace"]; ns != "preview" { //nolint:forcetypeassert
+			t.Errorf("%s/%s: unexpected namespace %v", obj["kind"], name, ns)
+		}
+	}
+
+	expected := []string{
+		"PersistentVolumeClaim/db-main",
+		"Deployment/docs",
+		"Secret/graphql-env",
+		"Deployment/graphql",
+		"Service/graphql",
+		"Ingress/graphql",
+		"Secret/postgres-env",
+		"ConfigMap/postgres-files-1",
+		"Deployment/postgres",
+		"Service/postgres",
+		"Deployment/sms",
+		"Service/sms",
+		"Service/api-twilio-com",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedPostgres := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":      "postgres",
+			"namespace": "preview",
+			"labels":    map[string]string{"app.kubernetes.io/part-of": "nhost"},
+		},
+		"spec": map[string]any{
+			"replicas": 1,
+			"selector": map[string]any{
+				"matchLabels": map[string]string{"app.kubernetes.io/name": "postgres"},
+			},
+			"strategy": map[string]string{"type": "Recreate"},
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"labels": map[string]string{"app.kubernetes.io/name": "postgres"},
+				},
+				"spec": map[string]any{
+					"containers": []map[string]any{
+						{
+							"name":  "postgres",
+							"image": "nhost/postgres:16",
+							"envFrom": []map[string]any{
+								{"secretRef": map[string]string{"name": "postgres-env"}},
+							},
+							"ports": []map[string]any{{"containerPort": uint(5432)}},
+							"readinessProbe": map[string]any{
+								"exec": map[string]any{
+									"command": []string{"sh", "-c", "pg_isready -U postgres -q"},
+								},
+								"periodSeconds":       5,
+								"timeoutSeconds":      60,
+								"initialDelaySeconds": 60,
+							},
+							"volumeMounts": []map[string]any{
+								{"name": "volume-0", "mountPath": "/var/lib/postgresql/data"},
+								{
+									"name":      "volume-1",
+									"mountPath": "/etc/pg_hba_local.conf",
+									"readOnly":  true,
+									"subPath":   "pg_hba_local.conf",
+								},
+							},
+						},
+					},
+					"volumes": []map[string]any{
+						{
+							"name":                  "volume-0",
+							"persistentVolumeClaim": map[string]string{"claimName": "db-main"},
+						},
+						{
+							"name": "volume-1",
+							"configMap": map[string]any{
+								"name": "postgres-files-1",
+								"items": []map[string]string{
+									{"key": "pg_hba_local.conf", "path": "pg_hba_local.conf"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedPostgres, objects["Deployment/postgres"]); diff != "" {
+		t.Error(diff)
+	}
+
+	container := objects["Deployment/graphql"]["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]map[string]any)[0] //nolint:lll,forcetypeassert
+	expectedResources := map[string]any{
+		"limits": map[string]string{"cpu": "0.5", "memory": "512Mi"},
+	}
+	if diff := cmp.Diff(expectedResources, container["resources"]); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedIngress := map[string]any{
+		"rules": []map[string]any{
+			{
+				"host": "*.graphql.local.nhost.run",
+				"http": map[string]any{"paths": []map[string]any{graphqlIngressPath()}},
+			},
+			{
+				"host": "local.graphql.nhost.run",
+				"http": map[string]any{"paths": []map[string]any{graphqlIngressPath()}},
+			},
+		},
+		"tls": []map[string]any{
+			{"hosts": []string{"*.graphql.local.nhost.run", "local.graphql.nhost.run"}},
+		},
+	}
+	if diff := cmp.Diff(expectedIngress, objects["Ingress/graphql"]["spec"]); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedWarnings := []string{
+		"docs-files-0: " + filepath.Join(dataFolder, "missing") +
+			" can't be read, it is exported as an empty folder",
+	}
+	if diff := cmp.Diff(expectedWarnings, manifests.Warnings); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func graphqlIngressPath() map[string]any {
+	return map[string]any{
+		"path":     "/v1",
+		"pathType": "Prefix",
+		"backend": map[string]any{
+			"service": map[string]any{
+				"name": "graphql",
+				"port": map[string]any{"number": uint(8080)},
+			},
+		},
+	}
+}
+
+func TestKubernetesConfigMap(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"index.js":                         "export default () => {}",
+		".secrets":                         "PASSWORD=secret",
+		filepath.Join(".git", "HEAD"):      "ref: refs/heads/main",
+		filepath.Join("node_modules", "a"): "module.exports = {}",
+	} {
+		if err := writeFile(filepath.Join(root, path), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	k := &kubernetesExport{
+		namespace: "",
+		manifests: &KubernetesManifests{Objects: nil, Warnings: nil},
+	}
+
+	if _, _, err := k.configMap("functions-files-0", root); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := k.manifests.Objects[0]["data"].(map[string]string)
+	if diff := cmp.Diff(map[string]string{"index.js": "export default () => {}"}, data); diff != "" {
+		t.Error(diff)
+	}
+
+	// sizes are checked before reading the files
+	large, err := os.Create(filepath.Join(root, "large.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer large.Close()
+
+	if err := large.Truncate(kubernetesConfigMapLimit + 1); err != nil {
+		t.Fatal(err)
+	}
+
+	source, _, err := k.configMap("functions-files-1", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := source["emptyDir"]; !ok {
+		t.Errorf("expected an empty folder, got %v", source)
+	}
+
+	if len(k.manifests.Objects) != 1 {
+		t.Errorf("expected no ConfigMap for the large folder, got %d objects", len(k.manifests.Objects))
+	}
+}
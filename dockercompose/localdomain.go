@@ -0,0 +1,253 @@
+package dockercompose
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nhost/cli/ssl"
+)
+
+// DefaultLocalDomain is the domain the environment is served at, its
+// certificates are bundled with the CLI and public DNS resolves it to 127.0.0.1.
+const DefaultLocalDomain = "local.nhost.run"
+
+// LocalDomain serves the environment at a domain other than DefaultLocalDomain
+// with certificates issued by a CA generated on this machine.
+type LocalDomain struct {
+	Domain string
+	// CAFolder is where the CA is kept, it is shared by all projects using the
+	// domain so it only needs to be trusted once. The CA can only issue
+	// certificates for the domain.
+	CAFolder string
+}
+
+// Name returns the domain the environment is served at.
+func (ld *LocalDomain) Name() string {
+	if ld == nil {
+		return DefaultLocalDomain
+	}
+
+	return ld.Domain
+}
+
+// replacer moves values from DefaultLocalDomain to the local domain, regular
+// expressions in traefik rules included.
+func (ld *LocalDomain) replacer() *strings.Replacer {
+	return strings.NewReplacer(
+		regexp.QuoteMeta("."+DefaultLocalDomain), regexp.QuoteMeta("."+ld.Domain),
+		"."+DefaultLocalDomain, "."+ld.Domain,
+	)
+}
+
+//nolint:gochecknoglobals
+var domainRegex = regexp.MustCompile(
+	`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`,
+)
+
+// ValidateLocalDomain checks the domain can be used to serve the environment.
+func ValidateLocalDomain(domain string) error {
+	if !domainRegex.MatchString(domain) {
+		return fmt.Errorf("invalid local domain %q", domain) //nolint:err113
+	}
+
+	return nil
+}
+
+// localDomainIngresses are the names services are reachable at through traefik,
+// i.e. <subdomain>.<name>.<domain>.
+func localDomainIngresses(runServices ...*RunService) []string {
+	names := []string{
+		"auth",
+		"dashboard",
+		"db",
+		"functions",
+		"grafana",
+		"graphql",
+		"hasura",
+		"mailhog",
+		"storage",
+	}
+
+	for _, runService := range runServices {
+		for _, name := range RunIngressNames(runService.Config) {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// LocalDomainHosts returns the hosts the environment is served at so they can
+// be resolved without DNS.
+func LocalDomainHosts(subdomain, domain string, runServices ...*RunService) []string {
+	names := localDomainIngresses(runServices...)
+
+	hosts := make([]string, len(names))
+	for i, name := range names {
+		hosts[i] = fmt.Sprintf("%s.%s.%s", subdomain, name, domain)
+	}
+
+	return hosts
+}
+
+const traefikLocalDomainConfig = `
+# v1
+# DO NOT EDIT THIS FILE
+tls:
+  certificates:
+    - certFile: /opt/traefik/certs/local.crt
+      keyFile: /opt/traefik/certs/local.key
+log:
+  level: DEBUG
+accessLog: {}
+`
+
+// localDomainFiles replaces the bundled certificates with a wildcard
+// certificate for the domain signed by the local CA.
+func localDomainFiles(
+	localDomain *LocalDomain, dotNhostFolder string, runServices ...*RunService,
+) error {
+	ca, err := ssl.LoadOrCreateCA(localDomain.CAFolder, localDomain.Domain)
+	if err != nil {
+		return fmt.Errorf("failed to load local CA: %w", err)
+	}
+
+	names := localDomainIngresses(runServices...)
+
+	dnsNames := make([]string, len(names))
+	for i, name := range names {
+		dnsNames[i] = "*." + name + "." + localDomain.Domain
+	}
+
+	certPEM, keyPEM, err := ca.IssueCert(dnsNames...)
+	if err != nil {
+		return fmt.Errorf("failed to issue certificate for %s: %w", localDomain.Domain, err)
+	}
+
+	if err := dumpCert(certPEM, keyPEM, "local", dotNhostFolder); err != nil {
+		return fmt.Errorf("failed to dump local cert: %w", err)
+	}
+
+	for _, f := range []string{"sub.crt", "sub.key"} {
+		err := os.Remove(filepath.Join(dotNhostFolder, "traefik", "certs", f))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", f, err)
+		}
+	}
+
+	return writeFile(
+		filepath.Join(dotNhostFolder, "traefik", "traefik.yaml"), traefikLocalDomainConfig,
+	)
+}
+
+// adaptToDomain moves the services from DefaultLocalDomain to the local domain
+// and makes them trust the local CA as they reach each other through traefik.
+// Both the values we generate and the ones from the project's configuration are
+// rewritten so the environment is consistent.
+func adaptToDomain(services map[string]*Service, localDomain *LocalDomain) {
+	replacer := localDomain.replacer()
+
+	for name, svc := range services {
+		for i, host := range svc.ExtraHosts {
+			svc.ExtraHosts[i] = replacer.Replace(host)
+		}
+
+		for i, arg := range svc.Command {
+			svc.Command[i] = replacer.Replace(arg)
+		}
+
+		for k, v := range svc.Environment {
+			svc.Environment[k] = replacer.Replace(v)
+		}
+
+		for k, v := range svc.Labels {
+			svc.Labels[k] = replacer.Replace(v)
+		}
+
+		if name != "traefik" {
+			trustCA(svc, filepath.Join(localDomain.CAFolder, "ca.crt"))
+		}
+	}
+}
+
+// trustCA mounts the CA certificates in caCert so node and go services accept
+// the certificates they issue.
+func trustCA(svc *Service, caCert string) {
+	if svc.Environment == nil {
+		svc.Environment = map[string]string{}
+	}
+
+	if svc.Environment["NODE_EXTRA_CA_CERTS"] == "/opt/nhost/ca/ca.crt" {
+		return
+	}
+
+	svc.Environment["NODE_EXTRA_CA_CERTS"] = "/opt/nhost/ca/ca.crt"
+	svc.Environment["SSL_CERT_DIR"] = "/etc/ssl/certs:/opt/nhost/ca"
+
+	svc.Volumes = append(svc.Volumes, Volume{
+		Type:     "bind",
+		Source:   caCert,
+		Target:   "/opt/nhost/ca/ca.crt",
+		ReadOnly: ptr(true),
+	})
+}
+
+const (
+	hostsBegin = "# BEGIN nhost %s"
+	hostsEnd   = "# END nhost %s"
+)
+
+// WriteHostsFile points the hosts to 127.0.0.1 in the hosts file at path. The
+// entries are kept in a block owned by the project so writing them again
+// replaces the previous ones.
+func WriteHostsFile(path, projectName string, hosts []string) error {
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	begin := fmt.Sprintf(hostsBegin, projectName)
+	end := fmt.Sprintf(hostsEnd, projectName)
+
+	lines := make([]string, 0)
+	skip := false
+
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == begin:
+			skip = true
+		case line == end:
+			skip = false
+		case !skip:
+			lines = append(lines, line)
+		}
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	lines = append(lines, "", begin)
+	for _, host := range hosts {
+		lines = append(lines, "127.0.0.1 "+host)
+	}
+
+	lines = append(lines, end, "")
+
+	if err := os.WriteFile( //nolint:gosec
+		path, []byte(strings.Join(lines, "\n")), 0o644, //nolint:mnd
+	); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdaptToDomain(t *testing.T) {
+	t.Parallel()
+
+	localDomain := &LocalDomain{Domain: "nhost.test", CAFolder: "/home/user/.nhost/state/ca"}
+
+	services := map[string]*Service{
+		"auth": { //nolint:exhaustruct
+			Command: []string{"--api-host", "https://local.hasura.local.nhost.run"},
+			Environment: map[string]string{
+				"AUTH_SERVER_URL": URL("local", "auth", 443, true) + "/v1", //nolint:mnd
+			},
+			ExtraHosts: extraHosts("local")[:2],
+			Labels: Ingresses{
+				{
+					Name:    "auth",
+					TLS:     true,
+					Rule:    traefikHostMatch("auth"),
+					Port:    4000, //nolint:mnd
+					Rewrite: nil,
+				},
+			}.Labels(),
+		},
+		"traefik": {}, //nolint:exhaustruct
+	}
+
+	adaptToDomain(services, localDomain)
+
+	auth := services["auth"]
+
+	if diff := cmp.Diff(
+		[]string{"--api-host", "https://local.hasura.nhost.test"}, auth.Command,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(
+		map[string]string{
+			"AUTH_SERVER_URL":     "https://local.auth.nhost.test/v1",
+			"NODE_EXTRA_CA_CERTS": "/opt/nhost/ca/ca.crt",
+			"SSL_CERT_DIR":        "/etc/ssl/certs:/opt/nhost/ca",
+		},
+		auth.Environment,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(
+		[]string{"host.docker.internal:host-gateway", "local.auth.nhost.test:host-gateway"},
+		auth.ExtraHosts,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(
+		"(HostRegexp(`^.+\\.auth\\.nhost\\.test$`) || Host(`local.auth.nhost.run`))",
+		auth.Labels["traefik.http.routers.auth.rule"],
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(
+		[]Volume{
+			{
+				Type:     "bind",
+				Source:   "/home/user/.nhost/state/ca/ca.crt",
+				Target:   "/opt/nhost/ca/ca.crt",
+				ReadOnly: ptr(true),
+			},
+		},
+		auth.Volumes,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	composeFile := &ComposeFile{Services: services, Volumes: nil, Networks: nil}
+	if diff := cmp.Diff("nhost.test", composeFile.LocalDomain()); diff != "" {
+		t.Error(diff)
+	}
+
+	if services["traefik"].Volumes != nil {
+		t.Error("expected traefik to be left untouched")
+	}
+
+	// services already trusting the CA, i.e. auth with the sms catcher, aren't patched twice
+	adaptToDomain(services, localDomain)
+
+	if len(auth.Volumes) != 1 {
+		t.Errorf("expected the CA to be mounted once, got %v", auth.Volumes)
+	}
+}
+
+func TestLocalDomainFiles(t *testing.T) {
+	t.Parallel()
+
+	dotNhostFolder := t.TempDir()
+	localDomain := &LocalDomain{Domain: "nhost.test", CAFolder: t.TempDir()}
+
+	if err := trafikFiles(dotNhostFolder); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := localDomainFiles(localDomain, dotNhostFolder); err != nil {
+		t.Fatal(err)
+	}
+
+	for f, exists := range map[string]bool{
+		"certs/local.crt": true,
+		"certs/local.key": true,
+		"certs/sub.crt":   false,
+		"certs/sub.key":   false,
+	} {
+		_, err := os.Stat(filepath.Join(dotNhostFolder, "traefik", f))
+		if (err == nil) != exists {
+			t.Errorf("%s: expected exists to be %t, got error %v", f, exists, err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dotNhostFolder, "traefik", "traefik.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(traefikLocalDomainConfig, string(b)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWriteHostsFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("127.0.0.1 localhost\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteHostsFile(path, "myapp", []string{"local.auth.nhost.test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// writing again replaces the project's entries
+	if err := WriteHostsFile(
+		path, "myapp", LocalDomainHosts("local", "nhost.test")[:2],
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `127.0.0.1 localhost
+
+# BEGIN nhost myapp
+127.0.0.1 local.auth.nhost.test
+127.0.0.1 local.dashboard.nhost.test
+# END nhost myapp
+`
+	if diff := cmp.Diff(expected, string(b)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,244 @@
+package dockercompose
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	LogLevelTrace = "trace"
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+	LogLevelFatal = "fatal"
+)
+
+//nolint:gochecknoglobals
+var logLevelSeverity = map[string]int{
+	LogLevelTrace: 0,
+	LogLevelDebug: 1,
+	LogLevelInfo:  2, //nolint:mnd
+	LogLevelWarn:  3, //nolint:mnd
+	LogLevelError: 4, //nolint:mnd
+	LogLevelFatal: 5, //nolint:mnd
+}
+
+// LogLevelAtLeast reports whether level is as severe as minimum. Entries without a
+// recognized level only pass when there is no minimum.
+func LogLevelAtLeast(level, minimum string) bool {
+	if minimum == "" {
+		return true
+	}
+
+	l, ok := logLevelSeverity[level]
+	if !ok {
+		return false
+	}
+
+	return l >= logLevelSeverity[minimum]
+}
+
+func IsLogLevel(level string) bool {
+	_, ok := logLevelSeverity[level]
+	return ok
+}
+
+type LogEntry struct {
+	Service   string         `json:"service"`
+	Timestamp time.Time      `json:"timestamp"`
+	Level     string         `json:"level,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+func normalizeLogLevel(v any) string {
+	switch l := v.(type) {
+	case float64:
+		// pino numeric levels
+		switch {
+		case l >= 60: //nolint:mnd
+			return LogLevelFatal
+		case l >= 50: //nolint:mnd
+			return LogLevelError
+		case l >= 40: //nolint:mnd
+			return LogLevelWarn
+		case l >= 30: //nolint:mnd
+			return LogLevelInfo
+		case l >= 20: //nolint:mnd
+			return LogLevelDebug
+		default:
+			return LogLevelTrace
+		}
+	case string:
+		switch l = strings.ToLower(l); l {
+		case "warning":
+			return LogLevelWarn
+		case "err":
+			return LogLevelError
+		case "panic", "critical":
+			return LogLevelFatal
+		default:
+			return l
+		}
+	default:
+		return ""
+	}
+}
+
+func stringField(fields map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := fields[k].(string); ok && v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// hasuraMessage summarizes the structured logs of graphql-engine, which don't
+// have a message but a type and a detail object.
+func hasuraMessage(fields map[string]any) string {
+	logType, _ := fields["type"].(string)
+
+	switch detail := fields["detail"].(type) {
+	case string:
+		return logType + ": " + detail
+	case map[string]any:
+		parts := make([]string, 0)
+
+		if httpInfo, ok := detail["http_info"].(map[string]any); ok {
+			parts = append(parts, fmt.Sprintf(
+				"%v %v %v", httpInfo["method"], httpInfo["url"], httpInfo["status"],
+			))
+		}
+
+		if op, ok := detail["operation"].(map[string]any); ok {
+			if e, ok := op["error"].(map[string]any); ok {
+				parts = append(parts, fmt.Sprintf("%v (%v)", e["error"], e["code"]))
+			}
+		}
+
+		if msg := stringField(detail, "message", "msg", "error"); msg != "" {
+			parts = append(parts, msg)
+		}
+
+		if info, ok := detail["info"].(map[string]any); ok {
+			if msg := stringField(info, "message", "msg"); msg != "" {
+				parts = append(parts, msg)
+			}
+		}
+
+		if len(parts) == 0 {
+			return logType
+		}
+
+		return logType + ": " + strings.Join(parts, " ")
+	default:
+		return logType
+	}
+}
+
+func parseJSONLog(entry *LogEntry, message string) bool {
+	if !strings.HasPrefix(message, "{") {
+		return false
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(message), &fields); err != nil {
+		return false
+	}
+
+	entry.Fields = fields
+	entry.Level = normalizeLogLevel(fields["level"])
+	entry.Message = stringField(fields, "msg", "message")
+
+	if entry.Message == "" {
+		if _, ok := fields["type"]; ok {
+			entry.Message = hasuraMessage(fields)
+		}
+	}
+
+	if entry.Message == "" {
+		entry.Message = message
+	}
+
+	return true
+}
+
+//nolint:gochecknoglobals
+var (
+	logfmtLevelRe   = regexp.MustCompile(`\blevel=("?)(\w+)`)
+	postgresLevelRe = regexp.MustCompile(`\b(DEBUG\d?|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC):\s`)
+)
+
+func textLogLevel(message string) string {
+	if m := logfmtLevelRe.FindStringSubmatch(message); m != nil {
+		return normalizeLogLevel(m[2])
+	}
+
+	if m := postgresLevelRe.FindStringSubmatch(message); m != nil {
+		switch m[1] {
+		case "LOG", "INFO", "NOTICE":
+			return LogLevelInfo
+		default:
+			if strings.HasPrefix(m[1], "DEBUG") {
+				return LogLevelDebug
+			}
+
+			return normalizeLogLevel(m[1])
+		}
+	}
+
+	return ""
+}
+
+// logService extracts the service name from the prefix docker compose adds to
+// each line, which is either <service>-<n> or <project>-<service>-<n>.
+func logService(prefix, projectName string) string {
+	prefix = strings.TrimSpace(prefix)
+	prefix = strings.TrimPrefix(prefix, projectName+"-")
+
+	if i := strings.LastIndex(prefix, "-"); i > 0 {
+		if strings.Trim(prefix[i+1:], "0123456789") == "" {
+			prefix = prefix[:i]
+		}
+	}
+
+	return prefix
+}
+
+// ParseLogLine parses a line printed by `docker compose logs --no-color --timestamps`.
+func ParseLogLine(line, projectName string) LogEntry {
+	entry := LogEntry{
+		Service:   "",
+		Timestamp: time.Time{},
+		Level:     "",
+		Message:   line,
+		Fields:    nil,
+	}
+
+	prefix, rest, ok := strings.Cut(line, " | ")
+	if !ok {
+		return entry
+	}
+
+	entry.Service = logService(prefix, projectName)
+	entry.Message = rest
+
+	if ts, message, ok := strings.Cut(rest, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			entry.Timestamp = t
+			entry.Message = message
+		}
+	}
+
+	if !parseJSONLog(&entry, entry.Message) {
+		entry.Level = textLogLevel(entry.Message)
+	}
+
+	return entry
+}
@@ -0,0 +1,117 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseLogLine(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC)
+
+	//nolint:lll
+	cases := []struct {
+		name     string
+		line     string
+		expected LogEntry
+	}{
+		{
+			name: "graphql-engine http-log",
+			line: `graphql-1  | 2024-05-01T10:00:00.123Z {"type":"http-log","timestamp":"2024-05-01T10:00:00.123+0000","level":"error","detail":{"operation":{"error":{"error":"field 'foo' not found in type: 'query_root'","code":"validation-failed"}},"http_info":{"method":"POST","url":"/v1/graphql","status":200}}}`,
+			expected: LogEntry{
+				Service:   "graphql",
+				Timestamp: ts,
+				Level:     LogLevelError,
+				Message:   "http-log: POST /v1/graphql 200 field 'foo' not found in type: 'query_root' (validation-failed)",
+			},
+		},
+		{
+			name: "hasura-storage logrus",
+			line: `myproject-storage-1  | 2024-05-01T10:00:00.123Z {"level":"warning","msg":"file too big","time":"2024-05-01T10:00:00Z"}`,
+			expected: LogEntry{
+				Service:   "storage",
+				Timestamp: ts,
+				Level:     LogLevelWarn,
+				Message:   "file too big",
+			},
+		},
+		{
+			name: "pino numeric level",
+			line: `auth-1  | 2024-05-01T10:00:00.123Z {"level":50,"msg":"invalid refresh token"}`,
+			expected: LogEntry{
+				Service:   "auth",
+				Timestamp: ts,
+				Level:     LogLevelError,
+				Message:   "invalid refresh token",
+			},
+		},
+		{
+			name: "postgres",
+			line: `postgres-1  | 2024-05-01T10:00:00.123Z 2024-05-01 10:00:00.123 UTC [1] ERROR:  relation "foo" does not exist`,
+			expected: LogEntry{
+				Service:   "postgres",
+				Timestamp: ts,
+				Level:     LogLevelError,
+				Message:   `2024-05-01 10:00:00.123 UTC [1] ERROR:  relation "foo" does not exist`,
+			},
+		},
+		{
+			name: "plain text",
+			line: `mailhog-1  | 2024-05-01T10:00:00.123Z [HTTP] Binding to address: 0.0.0.0:8025`,
+			expected: LogEntry{
+				Service:   "mailhog",
+				Timestamp: ts,
+				Level:     "",
+				Message:   "[HTTP] Binding to address: 0.0.0.0:8025",
+			},
+		},
+		{
+			name: "no prefix",
+			line: `something else`,
+			expected: LogEntry{
+				Service:   "",
+				Timestamp: time.Time{},
+				Level:     "",
+				Message:   "something else",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ParseLogLine(tc.line, "myproject")
+			if diff := cmp.Diff(
+				tc.expected, got, cmpopts.IgnoreFields(LogEntry{}, "Fields"),
+			); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLogLevelAtLeast(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		level    string
+		minimum  string
+		expected bool
+	}{
+		{level: LogLevelError, minimum: LogLevelWarn, expected: true},
+		{level: LogLevelInfo, minimum: LogLevelWarn, expected: false},
+		{level: "", minimum: LogLevelWarn, expected: false},
+		{level: "", minimum: "", expected: true},
+	}
+
+	for _, tc := range cases {
+		if got := LogLevelAtLeast(tc.level, tc.minimum); got != tc.expected {
+			t.Errorf("LogLevelAtLeast(%q, %q) = %v, want %v", tc.level, tc.minimum, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package dockercompose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// getInconsistentMetadata queries hasura from its own container so the admin
+// secret doesn't need to be known and TLS and DNS don't get in the way.
+const getInconsistentMetadata = `curl -sS -X POST http://localhost:8080/v1/metadata \
+  -H "x-hasura-admin-secret: $HASURA_GRAPHQL_ADMIN_SECRET" \
+  -H "Content-Type: application/json" \
+  -d '{"type":"get_inconsistent_metadata","args":{}}'`
+
+// InconsistentObject is an object of the metadata hasura couldn't load.
+type InconsistentObject struct {
+	// Type is hasura's type of the object, i.e. table, object_relation or
+	// select_permission.
+	Type string
+	// Table is the table the object belongs to as schema.name.
+	Table string
+	// Name is the name of the relationship or the role of the permission.
+	Name   string
+	Reason string
+}
+
+// Kind groups the types of objects, i.e. relationship for object_relation and
+// array_relation.
+func (o InconsistentObject) Kind() string {
+	switch {
+	case strings.HasSuffix(o.Type, "_relation"):
+		return "relationship"
+	case strings.HasSuffix(o.Type, "_permission"):
+		return "permission"
+	default:
+		return o.Type
+	}
+}
+
+// Object describes the object, i.e. public.posts.author for a relationship or
+// select for user on public.posts for a permission.
+func (o InconsistentObject) Object() string {
+	switch o.Kind() {
+	case "relationship":
+		return o.Table + "." + o.Name
+	case "permission":
+		return fmt.Sprintf(
+			"%s for %s on %s", strings.TrimSuffix(o.Type, "_permission"), o.Name, o.Table,
+		)
+	case "table":
+		return o.Table
+	default:
+		// hasura's names start with the type, i.e. remote_schema my_remote
+		return strings.TrimPrefix(o.Name, o.Type+" ")
+	}
+}
+
+// qualifiedTable is either a table name in the public schema or an object with
+// the schema and the name.
+type qualifiedTable string
+
+func (t *qualifiedTable) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*t = qualifiedTable("public." + name)
+		return nil
+	}
+
+	var table struct {
+		Schema string `json:"schema"`
+		Name   string `json:"name"`
+	}
+	if err := json.Unmarshal(b, &table); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if table.Schema == "" {
+		table.Schema = "public"
+	}
+
+	*t = qualifiedTable(table.Schema + "." + table.Name)
+
+	return nil
+}
+
+type inconsistentMetadataResponse struct {
+	IsConsistent        bool `json:"is_consistent"`
+	InconsistentObjects []struct {
+		Type       string          `json:"type"`
+		Name       string          `json:"name"`
+		Reason     string          `json:"reason"`
+		Definition json.RawMessage `json:"definition"`
+	} `json:"inconsistent_objects"`
+	Error string `json:"error"`
+}
+
+func parseInconsistentMetadata(b []byte) ([]InconsistentObject, error) {
+	var resp inconsistentMetadataResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse inconsistent metadata: %w: %s", err, b)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("failed to get inconsistent metadata: %s", resp.Error) //nolint:err113
+	}
+
+	objects := make([]InconsistentObject, 0, len(resp.InconsistentObjects))
+
+	for _, obj := range resp.InconsistentObjects {
+		var definition struct {
+			Table qualifiedTable `json:"table"`
+			Name  string         `json:"name"`
+			Role  string         `json:"role"`
+		}
+		// definitions vary with the type, unknown ones are reported by name
+		_ = json.Unmarshal(obj.Definition, &definition)
+
+		o := InconsistentObject{
+			Type:   obj.Type,
+			Table:  string(definition.Table),
+			Name:   obj.Name,
+			Reason: obj.Reason,
+		}
+
+		switch o.Kind() {
+		case "relationship":
+			o.Name = definition.Name
+		case "permission":
+			o.Name = definition.Role
+		case "table":
+			if o.Table == "" {
+				var table qualifiedTable
+				if err := json.Unmarshal(obj.Definition, &table); err == nil {
+					o.Table = string(table)
+				}
+			}
+		}
+
+		objects = append(objects, o)
+	}
+
+	return objects, nil
+}
+
+// InconsistentMetadata returns the objects of the metadata hasura couldn't
+// load, i.e. relationships to columns that don't exist.
+func (dc *DockerCompose) InconsistentMetadata(ctx context.Context) ([]InconsistentObject, error) {
+	var stdout bytes.Buffer
+	if err := dc.Exec(
+		ctx, nil, &stdout, "graphql", "sh", "-c", getInconsistentMetadata,
+	); err != nil {
+		return nil, err
+	}
+
+	return parseInconsistentMetadata(stdout.Bytes())
+}
@@ -0,0 +1,89 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseInconsistentMetadata(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	resp := `{
+  "is_consistent": false,
+  "inconsistent_objects": [
+    {
+      "definition": {"name": "legacy", "schema": "public"},
+      "name": "table legacy in source default",
+      "reason": "Inconsistent object: no such table/view exists in source: \"legacy\"",
+      "type": "table"
+    },
+    {
+      "definition": {
+        "name": "author",
+        "source": "default",
+        "table": {"schema": "public", "name": "posts"},
+        "using": {"foreign_key_constraint_on": "author_id"}
+      },
+      "name": "object_relation author in table posts in source default",
+      "reason": "Inconsistent object: in table \"posts\": in relationship \"author\": no such column exists: \"author_id\"",
+      "type": "object_relation"
+    },
+    {
+      "definition": {
+        "role": "user",
+        "source": "default",
+        "table": "comments",
+        "permission": {"columns": ["body"], "filter": {}}
+      },
+      "name": "select_permission user in table comments in source default",
+      "reason": "Inconsistent object: in table \"comments\": in permission for role \"user\": no such column exists: \"body\"",
+      "type": "select_permission"
+    },
+    {
+      "definition": "my_remote",
+      "name": "remote_schema my_remote",
+      "reason": "Inconsistent object: HTTP exception occurred while sending the request",
+      "type": "remote_schema"
+    }
+  ]
+}`
+
+	got, err := parseInconsistentMetadata([]byte(resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"table public.legacy",
+		"relationship public.posts.author",
+		"permission select for user on public.comments",
+		"remote_schema my_remote",
+	}
+
+	objects := make([]string, 0, len(got))
+	for _, o := range got {
+		objects = append(objects, o.Kind()+" "+o.Object())
+	}
+
+	if diff := cmp.Diff(expected, objects); diff != "" {
+		t.Error(diff)
+	}
+
+	got, err = parseInconsistentMetadata(
+		[]byte(`{"is_consistent": true, "inconsistent_objects": []}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("expected no inconsistent objects, got %v", got)
+	}
+
+	if _, err := parseInconsistentMetadata(
+		[]byte(`{"error": "invalid x-hasura-admin-secret", "code": "access-denied"}`),
+	); err == nil {
+		t.Error("expected error when hasura returns an error")
+	}
+}
@@ -0,0 +1,224 @@
+package dockercompose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HasuraTarget is the hasura instance and the project hasura-cli runs against.
+type HasuraTarget struct {
+	Subdomain   string
+	NhostFolder string
+	Version     string
+	Endpoint    string
+	AdminSecret string
+	Database    string
+}
+
+type MigrationStatus struct {
+	Version         string
+	Name            string
+	SourcePresent   bool
+	DatabasePresent bool
+}
+
+// Pending reports whether the migration still has to be applied.
+func (m MigrationStatus) Pending() bool {
+	return m.SourcePresent && !m.DatabasePresent
+}
+
+// migrateCLI runs a hasura-cli migrate command without a terminal and returns
+// its output, hasura-cli logs to stderr so it is included to be parsed too.
+// volumes are mounted in the container in the format of `docker run -v`.
+func (d *Docker) migrateCLI(
+	ctx context.Context, target HasuraTarget, volumes []string, args ...string,
+) (string, error) {
+	args = append(
+		append([]string{"migrate"}, args...),
+		"--database-name", target.Database,
+		"--endpoint", target.Endpoint,
+		"--admin-secret", target.AdminSecret,
+		"--skip-update-check",
+		"--no-color",
+	)
+
+	cmd, err := d.hasuraCommand(
+		ctx, false, volumes, target.Subdomain, target.NhostFolder, target.Version, args...,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf(
+			"hasura-cli migrate %s failed: %w\n%s", args[1], err, strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return stdout.String() + stderr.String(), nil
+}
+
+// parseMigrateStatus parses the table printed by `hasura-cli migrate status`.
+// Columns are aligned with spaces and the statuses have spaces too so they are
+// split at the offsets of the headers.
+func parseMigrateStatus(out string) ([]MigrationStatus, error) {
+	var offsets []int
+
+	statuses := make([]MigrationStatus, 0)
+
+	for line := range strings.Lines(out) {
+		line = strings.TrimRight(line, "\r\n")
+
+		if offsets == nil {
+			if !strings.HasPrefix(line, "VERSION") {
+				continue
+			}
+
+			offsets = []int{
+				0,
+				strings.Index(line, "NAME"),
+				strings.Index(line, "SOURCE STATUS"),
+				strings.Index(line, "DATABASE STATUS"),
+			}
+			if slices.Contains(offsets, -1) {
+				return nil, fmt.Errorf("unexpected migrate status header: %s", line) //nolint:err113
+			}
+
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" || len(line) <= offsets[3] {
+			continue
+		}
+
+		column := func(i int) string {
+			end := len(line)
+			if i+1 < len(offsets) {
+				end = offsets[i+1]
+			}
+
+			return strings.TrimSpace(line[offsets[i]:end])
+		}
+
+		statuses = append(statuses, MigrationStatus{
+			Version:         column(0),
+			Name:            column(1),
+			SourcePresent:   column(2) == "Present",
+			DatabasePresent: column(3) == "Present",
+		})
+	}
+
+	if offsets == nil {
+		return nil, errors.New("migrate status table not found in hasura-cli output") //nolint:err113
+	}
+
+	return statuses, nil
+}
+
+//nolint:gochecknoglobals
+var migrationVersionRe = regexp.MustCompile(`(?:version=|Created ')(\d+)`)
+
+// migrationVersion returns the version hasura-cli logs after creating or
+// squashing migrations.
+func migrationVersion(out string) (string, error) {
+	match := migrationVersionRe.FindStringSubmatch(out)
+	if match == nil {
+		return "", errors.New("migration version not found in hasura-cli output") //nolint:err113
+	}
+
+	return match[1], nil
+}
+
+// MigrateStatus returns the migrations in the project and whether they are
+// applied.
+func (d *Docker) MigrateStatus(ctx context.Context, target HasuraTarget) ([]MigrationStatus, error) {
+	out, err := d.migrateCLI(ctx, target, nil, "status")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMigrateStatus(out)
+}
+
+// MigrateCreate creates a migration with the contents of sqlFile as its up
+// step, empty if not set, and returns its version. The file is mounted rather
+// than passed as an argument as schema dumps easily exceed the size allowed.
+func (d *Docker) MigrateCreate(
+	ctx context.Context, target HasuraTarget, name, sqlFile string,
+) (string, error) {
+	args := []string{"create", name}
+
+	var volumes []string
+
+	if sqlFile != "" {
+		absPath, err := filepath.Abs(sqlFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to get absolute path: %w", err)
+		}
+
+		volumes = []string{absPath + ":/tmp/up.sql:ro"}
+		args = append(args, "--sql-from-file", "/tmp/up.sql")
+	}
+
+	out, err := d.migrateCLI(ctx, target, volumes, args...)
+	if err != nil {
+		return "", err
+	}
+
+	return migrationVersion(out)
+}
+
+// MigrateSquash squashes the migrations from the version onwards into a new
+// one and marks it as applied as its steps already are. It returns the version
+// of the new migration.
+func (d *Docker) MigrateSquash(
+	ctx context.Context, target HasuraTarget, from, name string, deleteSource bool,
+) (string, error) {
+	args := []string{"squash", "--from", from, "--name", name}
+	if deleteSource {
+		args = append(args, "--delete-source")
+	}
+
+	out, err := d.migrateCLI(ctx, target, nil, args...)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := migrationVersion(out)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := d.migrateCLI(
+		ctx, target, nil, "apply", "--version", version, "--skip-execution",
+	); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+// MigrateDown rolls back the migration with the version.
+func (d *Docker) MigrateDown(
+	ctx context.Context, stdout io.Writer, target HasuraTarget, version string,
+) error {
+	out, err := d.migrateCLI(ctx, target, nil, "apply", "--version", version, "--type", "down")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(stdout, out)
+
+	return err //nolint:wrapcheck
+}
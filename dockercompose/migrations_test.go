@@ -0,0 +1,77 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMigrateStatus(t *testing.T) {
+	t.Parallel()
+
+	out := `time="2024-01-01T00:00:00Z" level=info msg="fetching migrate status..."
+VERSION        NAME                 SOURCE STATUS  DATABASE STATUS
+1601394066587  init                 Present        Present
+1601394066588  add_users_index      Present        Not Present
+1601394066589  drop_legacy          Not Present    Present
+
+`
+
+	got, err := parseMigrateStatus(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []MigrationStatus{
+		{Version: "1601394066587", Name: "init", SourcePresent: true, DatabasePresent: true},
+		{Version: "1601394066588", Name: "add_users_index", SourcePresent: true, DatabasePresent: false},
+		{Version: "1601394066589", Name: "drop_legacy", SourcePresent: false, DatabasePresent: true},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if !got[1].Pending() || got[0].Pending() || got[2].Pending() {
+		t.Error("expected only the migration missing in the database to be pending")
+	}
+
+	if _, err := parseMigrateStatus("FATA[0000] failed to connect"); err == nil {
+		t.Error("expected error when the table is missing")
+	}
+}
+
+func TestMigrationVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		out      string
+		expected string
+	}{
+		{
+			name:     "create",
+			out:      `time="2024-01-01T00:00:00Z" level=info msg="Migrations files created" name=init version=1601394066587`,
+			expected: "1601394066587",
+		},
+		{
+			name:     "squash",
+			out:      `time="2024-01-01T00:00:00Z" level=info msg="Created '1601394066590_squashed' after squashing '1601394066587' till '1601394066589'"`,
+			expected: "1601394066590",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := migrationVersion(tc.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
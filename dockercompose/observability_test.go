@@ -0,0 +1,79 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGrafana(t *testing.T) {
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+
+	got, err := grafana(
+		getConfig().GetObservability().GetGrafana(), "dev", 443, true, tmpdir, "grafana_test",
+	)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	expectedEnv := map[string]string{
+		"GF_ANALYTICS_REPORTING_ENABLED": "false",
+		"GF_PATHS_PROVISIONING":          "/etc/grafana/provisioning",
+		"GF_SECURITY_ADMIN_PASSWORD":     "grafanaAdminPassword",
+		"GF_SECURITY_ADMIN_USER":         "admin",
+		"GF_SERVER_ROOT_URL":             "https://dev.grafana.local.nhost.run",
+	}
+	if diff := cmp.Diff(expectedEnv, got.Environment); diff != "" {
+		t.Error(diff)
+	}
+
+	if got.Labels["traefik.http.routers.grafana.tls"] != "true" {
+		t.Errorf("expected grafana to be exposed through traefik with TLS, got %v", got.Labels)
+	}
+
+	b, err := os.ReadFile(
+		filepath.Join(tmpdir, "grafana", "provisioning", "datasources", "prometheus.yaml"),
+	)
+	if err != nil {
+		t.Fatalf("failed to read datasources: %v", err)
+	}
+
+	if string(b) != grafanaDatasources {
+		t.Errorf("unexpected datasources file: %s", b)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+
+	if _, err := prometheus("dev", tmpdir); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(tmpdir, "prometheus", "prometheus.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read prometheus config: %v", err)
+	}
+
+	if string(b) != prometheusConfig {
+		t.Errorf("unexpected prometheus config: %s", b)
+	}
+}
+
+func TestPostgresExporter(t *testing.T) {
+	t.Parallel()
+
+	got := postgresExporter("dev")
+
+	if diff := cmp.Diff(
+		map[string]DependsOn{"postgres": {Condition: "service_healthy"}}, got.DependsOn,
+	); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,87 @@
+package dockercompose
+
+import (
+	"bytes"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan describes the changes needed to go from one compose file to another.
+type Plan struct {
+	Create    []string
+	Recreate  []string
+	Remove    []string
+	Unchanged []string
+}
+
+func (p *Plan) HasChanges() bool {
+	return len(p.Create) > 0 || len(p.Recreate) > 0 || len(p.Remove) > 0
+}
+
+// Starts returns whether any of the services is created or recreated.
+func (p *Plan) Starts(names ...string) bool {
+	for _, name := range names {
+		if slices.Contains(p.Create, name) || slices.Contains(p.Recreate, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func serviceEqual(a, b *Service) bool {
+	ba, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bb, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(ba, bb)
+}
+
+// NewPlan diffs current and desired service by service. Services are compared by
+// their serialized definition so a compose file read from disk compares equal to
+// the one it was generated from.
+func NewPlan(current, desired *ComposeFile) *Plan {
+	plan := &Plan{
+		Create:    []string{},
+		Recreate:  []string{},
+		Remove:    []string{},
+		Unchanged: []string{},
+	}
+
+	var currentServices map[string]*Service
+	if current != nil {
+		currentServices = current.Services
+	}
+
+	for name, svc := range desired.Services {
+		old, ok := currentServices[name]
+		switch {
+		case !ok:
+			plan.Create = append(plan.Create, name)
+		case !serviceEqual(old, svc):
+			plan.Recreate = append(plan.Recreate, name)
+		default:
+			plan.Unchanged = append(plan.Unchanged, name)
+		}
+	}
+
+	for name := range currentServices {
+		if _, ok := desired.Services[name]; !ok {
+			plan.Remove = append(plan.Remove, name)
+		}
+	}
+
+	slices.Sort(plan.Create)
+	slices.Sort(plan.Recreate)
+	slices.Sort(plan.Remove)
+	slices.Sort(plan.Unchanged)
+
+	return plan
+}
@@ -0,0 +1,103 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewPlan(t *testing.T) {
+	t.Parallel()
+
+	roundTrip := func(t *testing.T, cf *ComposeFile) *ComposeFile {
+		t.Helper()
+
+		b, err := yaml.Marshal(cf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got ComposeFile
+		if err := yaml.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return &got
+	}
+
+	desired := func() *ComposeFile {
+		return &ComposeFile{
+			Services: map[string]*Service{
+				"graphql":  {Image: "nhost/graphql-engine:v2.36.0", DependsOn: map[string]DependsOn{}},
+				"postgres": {Image: "nhost/postgres:14", Command: []string{}},
+				"auth":     {Image: "nhost/hasura-auth:0.31.0", WorkingDir: new(string)},
+			},
+			Volumes:  map[string]struct{}{},
+			Networks: nil,
+		}
+	}
+
+	cases := []struct {
+		name     string
+		current  func(t *testing.T) *ComposeFile
+		expected *Plan
+		starts   bool
+	}{
+		{
+			name:    "nothing running",
+			current: func(*testing.T) *ComposeFile { return nil },
+			expected: &Plan{
+				Create:    []string{"auth", "graphql", "postgres"},
+				Recreate:  []string{},
+				Remove:    []string{},
+				Unchanged: []string{},
+			},
+			starts: true,
+		},
+		{
+			name:    "no changes",
+			current: func(t *testing.T) *ComposeFile { return roundTrip(t, desired()) },
+			expected: &Plan{
+				Create:    []string{},
+				Recreate:  []string{},
+				Remove:    []string{},
+				Unchanged: []string{"auth", "graphql", "postgres"},
+			},
+			starts: false,
+		},
+		{
+			name: "changes",
+			current: func(t *testing.T) *ComposeFile {
+				cf := desired()
+				cf.Services["graphql"].Image = "nhost/graphql-engine:v2.35.0"
+				cf.Services["mailhog"] = &Service{Image: "jcalonso/mailhog:v1.0.1"}
+				delete(cf.Services, "auth")
+
+				return roundTrip(t, cf)
+			},
+			expected: &Plan{
+				Create:    []string{"auth"},
+				Recreate:  []string{"graphql"},
+				Remove:    []string{"mailhog"},
+				Unchanged: []string{"postgres"},
+			},
+			starts: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewPlan(tc.current(t), desired())
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Error(diff)
+			}
+
+			if got.Starts("postgres", "graphql") != tc.starts {
+				t.Errorf("expected Starts to return %t", tc.starts)
+			}
+		})
+	}
+}
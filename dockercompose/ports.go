@@ -0,0 +1,44 @@
+package dockercompose
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+)
+
+type PublishedPort struct {
+	Service  string
+	Port     uint
+	Protocol string
+}
+
+// PublishedPorts returns the ports the services publish on the host sorted by
+// port.
+func (c *ComposeFile) PublishedPorts() []PublishedPort {
+	published := make([]PublishedPort, 0)
+
+	for name, svc := range c.Services {
+		for _, p := range svc.Ports {
+			port, err := strconv.ParseUint(p.Published, 10, 32)
+			if err != nil || port == 0 {
+				continue
+			}
+
+			published = append(published, PublishedPort{
+				Service:  name,
+				Port:     uint(port),
+				Protocol: p.Protocol,
+			})
+		}
+	}
+
+	slices.SortFunc(published, func(a, b PublishedPort) int {
+		return cmp.Or(
+			cmp.Compare(a.Port, b.Port),
+			cmp.Compare(a.Protocol, b.Protocol),
+			cmp.Compare(a.Service, b.Service),
+		)
+	})
+
+	return published
+}
@@ -0,0 +1,33 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPublishedPorts(t *testing.T) {
+	t.Parallel()
+
+	composeFile := &ComposeFile{
+		Services: map[string]*Service{
+			"traefik":  {Ports: ports(443, 443)},   //nolint:exhaustruct
+			"postgres": {Ports: ports(5432, 5432)}, //nolint:exhaustruct
+			"auth":     {Ports: ports(0, 4000)},    //nolint:exhaustruct
+			"run-dns": { //nolint:exhaustruct
+				Ports: []Port{{Mode: "ingress", Target: 53, Published: "5353", Protocol: "udp"}},
+			},
+		},
+		Volumes:  nil,
+		Networks: nil,
+	}
+
+	expected := []PublishedPort{
+		{Service: "traefik", Port: 443, Protocol: "tcp"},
+		{Service: "run-dns", Port: 5353, Protocol: "udp"},
+		{Service: "postgres", Port: 5432, Protocol: "tcp"},
+	}
+	if diff := cmp.Diff(expected, composeFile.PublishedPorts()); diff != "" {
+		t.Error(diff)
+	}
+}
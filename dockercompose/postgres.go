@@ -93,5 +93,7 @@ func postgres( //nolint:funlen
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}, nil
 }
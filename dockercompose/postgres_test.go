@@ -64,6 +64,8 @@ func expectedPostgres(tmpdir string) *Service {
 			},
 		},
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
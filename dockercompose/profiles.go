@@ -0,0 +1,145 @@
+package dockercompose
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const (
+	ProfileMinimal = "minimal"
+	ProfileBackend = "backend"
+	ProfileFull    = "full"
+)
+
+// OptionalServices lists the services that can be left out of the environment.
+// postgres, graphql and traefik are always started. "observability" stands for
+// grafana and the services feeding it, it is only started when asked for.
+func OptionalServices() []string {
+	return []string{
+		"ai",
+		"auth",
+		"console",
+		"dashboard",
+		"functions",
+		"mailhog",
+		"observability",
+		"storage",
+	}
+}
+
+// Profiles lists the accepted profiles.
+func Profiles() []string {
+	return []string{ProfileMinimal, ProfileBackend, ProfileFull}
+}
+
+// ServicesWithout returns the services to leave out for the profile plus the
+// ones listed explicitly. Observability is left out unless observability is
+// true as most of the time it isn't needed and it slows down `nhost up`.
+func ServicesWithout(profile string, without []string, observability bool) ([]string, error) {
+	var excluded []string
+
+	switch profile {
+	case ProfileFull, "":
+		excluded = []string{}
+	case ProfileBackend:
+		excluded = []string{"console", "dashboard"}
+	case ProfileMinimal:
+		excluded = slices.DeleteFunc(OptionalServices(), func(name string) bool {
+			return name == "observability"
+		})
+	default:
+		return nil, fmt.Errorf( //nolint:err113
+			"unknown profile %q, valid profiles are: %s",
+			profile, strings.Join(Profiles(), ", "),
+		)
+	}
+
+	if !observability {
+		excluded = append(excluded, "observability")
+	}
+
+	for _, name := range without {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(OptionalServices(), name) {
+			return nil, fmt.Errorf( //nolint:err113
+				"service %q can't be left out, valid services are: %s",
+				name, strings.Join(OptionalServices(), ", "),
+			)
+		}
+
+		if !slices.Contains(excluded, name) {
+			excluded = append(excluded, name)
+		}
+	}
+
+	slices.Sort(excluded)
+
+	return excluded, nil
+}
+
+// companions are only started to support another service so they are left out
+// with it.
+//
+//nolint:gochecknoglobals
+var companions = map[string][]string{
+	"auth":          {"sms"},
+	"dashboard":     {"configserver"},
+	"observability": {"grafana", "prometheus", "postgres-exporter"},
+	"storage":       {"minio", "clamav"},
+}
+
+// headless keeps the console container without the UI, migrations, metadata and
+// seeds are applied with the hasura-cli it ships.
+func headless(console *Service) {
+	console.Command = []string{"sleep", "infinity"}
+	console.HealthCheck = nil
+	console.Labels = nil
+	console.Ports = nil
+}
+
+// pruneServices removes the excluded services, their companions and, as they
+// couldn't start, the services depending on any of them.
+func pruneServices(services map[string]*Service, without []string) {
+	removed := make(map[string]struct{})
+
+	for _, name := range without {
+		if name == "console" {
+			if console, ok := services["console"]; ok {
+				headless(console)
+			}
+
+			continue
+		}
+
+		removed[name] = struct{}{}
+		for _, companion := range companions[name] {
+			removed[companion] = struct{}{}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for name, svc := range services {
+			if _, ok := removed[name]; ok {
+				continue
+			}
+
+			for dep := range svc.DependsOn {
+				if _, ok := removed[dep]; ok {
+					removed[name] = struct{}{}
+					changed = true
+
+					break
+				}
+			}
+		}
+	}
+
+	maps.DeleteFunc(services, func(name string, _ *Service) bool {
+		_, ok := removed[name]
+		return ok
+	})
+}
@@ -0,0 +1,197 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServicesWithout(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		profile       string
+		without       []string
+		observability bool
+		expected      []string
+		err           bool
+	}{
+		{
+			name:          "full",
+			profile:       ProfileFull,
+			without:       nil,
+			observability: false,
+			expected:      []string{"observability"},
+			err:           false,
+		},
+		{
+			name:          "full with observability",
+			profile:       ProfileFull,
+			without:       nil,
+			observability: true,
+			expected:      []string{},
+			err:           false,
+		},
+		{
+			name:          "backend with extra services",
+			profile:       ProfileBackend,
+			without:       []string{"mailhog", "dashboard"},
+			observability: false,
+			expected:      []string{"console", "dashboard", "mailhog", "observability"},
+			err:           false,
+		},
+		{
+			name:          "minimal",
+			profile:       ProfileMinimal,
+			without:       nil,
+			observability: false,
+			expected:      OptionalServices(),
+			err:           false,
+		},
+		{
+			name:          "minimal with observability",
+			profile:       ProfileMinimal,
+			without:       nil,
+			observability: true,
+			expected:      []string{"ai", "auth", "console", "dashboard", "functions", "mailhog", "storage"},
+			err:           false,
+		},
+		{
+			name:          "required service",
+			profile:       ProfileFull,
+			without:       []string{"postgres"},
+			observability: false,
+			expected:      nil,
+			err:           true,
+		},
+		{
+			name:          "unknown profile",
+			profile:       "tiny",
+			without:       nil,
+			observability: false,
+			expected:      nil,
+			err:           true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ServicesWithout(tc.profile, tc.without, tc.observability)
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func servicesForPruning() map[string]*Service {
+	dependsOn := func(names ...string) map[string]DependsOn {
+		d := make(map[string]DependsOn)
+		for _, name := range names {
+			d[name] = DependsOn{Condition: "service_healthy"}
+		}
+
+		return d
+	}
+
+	return map[string]*Service{
+		"postgres":     {DependsOn: nil},                                     //nolint:exhaustruct
+		"graphql":      {DependsOn: dependsOn("postgres")},                   //nolint:exhaustruct
+		"traefik":      {DependsOn: nil},                                     //nolint:exhaustruct
+		"minio":        {DependsOn: nil},                                     //nolint:exhaustruct
+		"clamav":       {DependsOn: nil},                                     //nolint:exhaustruct
+		"storage":      {DependsOn: dependsOn("graphql", "minio", "clamav")}, //nolint:exhaustruct
+		"mailhog":      {DependsOn: nil},                                     //nolint:exhaustruct
+		"sms":          {DependsOn: nil},                                     //nolint:exhaustruct
+		"auth":         {DependsOn: dependsOn("graphql", "sms")},             //nolint:exhaustruct
+		"ai":           {DependsOn: dependsOn("graphql", "auth")},            //nolint:exhaustruct
+		"dashboard":    {DependsOn: nil},                                     //nolint:exhaustruct
+		"configserver": {DependsOn: nil},                                     //nolint:exhaustruct
+		"console": { //nolint:exhaustruct
+			DependsOn: dependsOn("graphql"),
+			Command:   []string{"bash", "-c", "hasura-cli console"},
+			Labels:    map[string]string{"traefik.enable": "true"},
+			Ports:     []Port{{Mode: "ingress", Target: 9695, Published: "9695", Protocol: "tcp"}},
+		},
+	}
+}
+
+func TestPruneServices(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		without  []string
+		expected []string
+	}{
+		{
+			name:    "nothing",
+			without: []string{},
+			expected: []string{
+				"ai", "auth", "clamav", "configserver", "console", "dashboard", "graphql",
+				"mailhog", "minio", "postgres", "sms", "storage", "traefik",
+			},
+		},
+		{
+			name:    "storage takes minio and clamav",
+			without: []string{"storage"},
+			expected: []string{
+				"ai", "auth", "configserver", "console", "dashboard", "graphql",
+				"mailhog", "postgres", "sms", "traefik",
+			},
+		},
+		{
+			name:    "auth takes the services depending on it",
+			without: []string{"auth", "dashboard"},
+			expected: []string{
+				"clamav", "console", "graphql", "mailhog", "minio", "postgres", "storage", "traefik",
+			},
+		},
+		{
+			name:     "minimal",
+			without:  OptionalServices(),
+			expected: []string{"console", "graphql", "postgres", "traefik"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			services := servicesForPruning()
+			pruneServices(services, tc.without)
+
+			got := make([]string, 0, len(services))
+			for name := range services {
+				got = append(got, name)
+			}
+
+			slices.Sort(got)
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Error(diff)
+			}
+
+			console := services["console"]
+			if slices.Contains(tc.without, "console") {
+				if diff := cmp.Diff([]string{"sleep", "infinity"}, console.Command); diff != "" {
+					t.Error(diff)
+				}
+
+				if console.Labels != nil || console.Ports != nil {
+					t.Error("expected headless console to have no ingress nor ports")
+				}
+			} else if console.Labels == nil {
+				t.Error("expected console to keep its ingress")
+			}
+		})
+	}
+}
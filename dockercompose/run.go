@@ -15,19 +15,164 @@ func runVolumeName(
 	return fmt.Sprintf("%s-run-%s-%s", sanitizeBranch(branchName), runName, volumeName)
 }
 
+// defaults match the ones in the config schema
+const (
+	runHealthCheckDefaultInitialDelay = 30
+	runHealthCheckDefaultProbePeriod  = 60
+)
+
+// docker runs the first check an interval after the container starts so the
+// probe period is capped, otherwise `up` would wait for a whole period. The
+// start_interval option would do this only during the start period but it
+// requires docker engine 25.
+const runHealthCheckMaxInterval = 5
+
+// runHealthCheck mimics the TCP probe done in the cloud. Images are arbitrary so
+// we try nc first and fallback to bash's /dev/tcp, this requires the image to
+// have a shell so images without one (i.e. distroless or scratch) must not
+// configure a health check or they never become healthy.
+func runHealthCheck(cfg *model.ConfigHealthCheck) *HealthCheck {
+	if cfg == nil {
+		return nil
+	}
+
+	initialDelay := runHealthCheckDefaultInitialDelay
+	if cfg.GetInitialDelaySeconds() != nil {
+		initialDelay = *cfg.GetInitialDelaySeconds()
+	}
+
+	probePeriod := runHealthCheckDefaultProbePeriod
+	if cfg.GetProbePeriodSeconds() != nil {
+		probePeriod = *cfg.GetProbePeriodSeconds()
+	}
+
+	probePeriod = min(probePeriod, runHealthCheckMaxInterval)
+
+	return &HealthCheck{
+		Test: []string{
+			"CMD-SHELL",
+			fmt.Sprintf(
+				"nc -z 127.0.0.1 %[1]d || bash -c 'echo > /dev/tcp/127.0.0.1/%[1]d'",
+				cfg.GetPort(),
+			),
+		},
+		Timeout:     "5s",
+		Interval:    fmt.Sprintf("%ds", probePeriod),
+		StartPeriod: fmt.Sprintf("%ds", initialDelay),
+	}
+}
+
+func runDependsOn(services map[string]*Service, runService *RunService) error {
+	svc := services["run-"+runService.Config.Name]
+
+	for _, name := range runService.DependsOn {
+		dep, ok := services[name]
+		if !ok {
+			return fmt.Errorf( //nolint:err113
+				"run service %s depends on unknown service %s", runService.Config.Name, name,
+			)
+		}
+
+		condition := "service_started"
+		if dep.HealthCheck != nil {
+			condition = "service_healthy"
+		}
+
+		svc.DependsOn[name] = DependsOn{Condition: condition}
+	}
+
+	return nil
+}
+
+// RunIngressNames returns the name each published HTTP port of the run service is
+// reachable at through traefik, i.e. <subdomain>.<name>.local.nhost.run. When there
+// is more than one the port is added to the name to tell them apart.
+func RunIngressNames(cfg *model.ConfigRunServiceConfig) map[uint16]string {
+	httpPorts := make([]uint16, 0)
+	for _, p := range cfg.GetPorts() {
+		if deptr(p.GetPublish()) && p.GetType() == "http" {
+			httpPorts = append(httpPorts, p.GetPort())
+		}
+	}
+
+	names := make(map[uint16]string, len(httpPorts))
+	for _, port := range httpPorts {
+		if len(httpPorts) == 1 {
+			names[port] = "run-" + cfg.GetName()
+		} else {
+			names[port] = fmt.Sprintf("run-%s-%d", cfg.GetName(), port)
+		}
+	}
+
+	return names
+}
+
+func runIngresses(cfg *model.ConfigRunServiceConfig, useTLS bool) Ingresses {
+	names := RunIngressNames(cfg)
+
+	ingresses := make(Ingresses, 0, len(names))
+	for _, p := range cfg.GetPorts() {
+		name, ok := names[p.GetPort()]
+		if !ok {
+			continue
+		}
+
+		rule := traefikHostMatch(name)
+		for _, ingress := range p.GetIngresses() {
+			for _, fqdn := range ingress.GetFqdn() {
+				rule += fmt.Sprintf(" || Host(`%s`)", fqdn)
+			}
+		}
+
+		ingresses = append(ingresses, Ingress{
+			Name:    name,
+			TLS:     useTLS,
+			Rule:    rule,
+			Port:    uint(p.GetPort()),
+			Rewrite: nil,
+		})
+	}
+
+	return ingresses
+}
+
+func runDeploy(cfg *model.ConfigRunServiceConfig) *Deploy {
+	compute := cfg.GetResources().GetCompute()
+
+	var resources *Resources
+	if compute != nil {
+		resources = &Resources{
+			Limits: &ResourceLimits{
+				CPUs:   strconv.FormatFloat(float64(compute.GetCpu())/1000, 'f', -1, 64), //nolint:mnd
+				Memory: fmt.Sprintf("%dM", compute.GetMemory()),
+			},
+			Reservations: nil,
+		}
+	}
+
+	return &Deploy{
+		Replicas:  cfg.GetResources().GetReplicas(),
+		Resources: resources,
+	}
+}
+
 func run(
 	cfg *model.ConfigRunServiceConfig,
 	subdomain string,
 	branchName string,
+	useTLS bool,
+	enforceResources bool,
 ) *Service {
 	env := map[string]string{}
 	for _, e := range cfg.GetEnvironment() {
 		env[e.GetName()] = e.GetValue()
 	}
 
+	// published HTTP ports are routed through traefik like in the cloud, the rest
+	// are published on the host
 	ports := make([]Port, 0, len(cfg.GetPorts()))
 	for _, p := range cfg.GetPorts() {
-		if deptr(p.GetPublish()) {
+		if deptr(p.GetPublish()) && p.GetType() != "http" {
 			proto := "tcp"
 			if p.GetType() == "udp" {
 				proto = p.GetType()
@@ -52,6 +197,16 @@ func run(
 		})
 	}
 
+	var deploy *Deploy
+	if enforceResources {
+		deploy = runDeploy(cfg)
+	}
+
+	labels := map[string]string{}
+	if ingresses := runIngresses(cfg, useTLS); len(ingresses) > 0 {
+		labels = ingresses.Labels()
+	}
+
 	return &Service{
 		Image:       cfg.GetImage().GetImage(),
 		DependsOn:   map[string]DependsOn{},
@@ -59,11 +214,13 @@ func run(
 		Command:     []string{},
 		Environment: env,
 		ExtraHosts:  extraHosts(subdomain),
-		HealthCheck: nil,
-		Labels:      map[string]string{},
+		HealthCheck: runHealthCheck(cfg.GetHealthCheck()),
+		Labels:      labels,
 		Ports:       ports,
 		Restart:     "always",
 		Volumes:     volumes,
 		WorkingDir:  nil,
+		Deploy:      deploy,
+		Networks:    nil,
 	}
 }
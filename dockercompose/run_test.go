@@ -50,7 +50,7 @@ func TestRun(t *testing.T) {
 						{
 							Port:      3000,
 							Type:      "tcp",
-							Publish:   ptr(false),
+							Publish:   ptr(true),
 							Ingresses: nil,
 						},
 						{
@@ -105,13 +105,30 @@ func TestRun(t *testing.T) {
 						"local.hasura.nhost.run:host-gateway",
 						"local.storage.nhost.run:host-gateway",
 					},
-					Labels: map[string]string{},
+					Labels: map[string]string{
+						"traefik.enable": "true",
+						"traefik.http.routers.run-service-name.entrypoints":               "web",
+						"traefik.http.routers.run-service-name.rule":                      "(HostRegexp(`^.+\\.run-service-name\\.local\\.nhost\\.run$`) || Host(`local.run-service-name.nhost.run`)) || Host(`svc.domain.com`)", //nolint:lll
+						"traefik.http.routers.run-service-name.service":                   "run-service-name",
+						"traefik.http.routers.run-service-name.tls":                       "false",
+						"traefik.http.services.run-service-name.loadbalancer.server.port": "80",
+					},
 					Ports: []Port{
-						{Mode: "ingress", Target: 80, Published: "80", Protocol: "tcp"},
+						{Mode: "ingress", Target: 3000, Published: "3000", Protocol: "tcp"},
+					},
+					Restart:    "always",
+					WorkingDir: nil,
+					Deploy:     nil,
+					Networks:   nil,
+					HealthCheck: &HealthCheck{
+						Test: []string{
+							"CMD-SHELL",
+							"nc -z 127.0.0.1 80 || bash -c 'echo > /dev/tcp/127.0.0.1/80'",
+						},
+						Timeout:     "5s",
+						Interval:    "5s",
+						StartPeriod: "10s",
 					},
-					Restart:     "always",
-					WorkingDir:  nil,
-					HealthCheck: nil,
 					Volumes: []Volume{
 						{
 							Type:     "volume",
@@ -129,10 +146,105 @@ func TestRun(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			got := run(tc.cfg(), "dev", "branch")
+			got := run(tc.cfg(), "dev", "branch", tc.useTlS, false)
 			if diff := cmp.Diff(tc.expected(), got); diff != "" {
 				t.Error(diff)
 			}
 		})
 	}
 }
+
+func TestRunDependsOn(t *testing.T) {
+	t.Parallel()
+
+	services := map[string]*Service{
+		"graphql":    {HealthCheck: &HealthCheck{}},       //nolint:exhaustruct
+		"mailhog":    {},                                  //nolint:exhaustruct
+		"run-my-svc": {DependsOn: map[string]DependsOn{}}, //nolint:exhaustruct
+	}
+
+	runService := &RunService{
+		Config:    &model.ConfigRunServiceConfig{Name: "my-svc"}, //nolint:exhaustruct
+		Path:      "",
+		DependsOn: []string{"graphql", "mailhog"},
+	}
+
+	if err := runDependsOn(services, runService); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]DependsOn{
+		"graphql": {Condition: "service_healthy"},
+		"mailhog": {Condition: "service_started"},
+	}
+	if diff := cmp.Diff(expected, services["run-my-svc"].DependsOn); diff != "" {
+		t.Error(diff)
+	}
+
+	runService.DependsOn = []string{"unknown"}
+	if err := runDependsOn(services, runService); err == nil {
+		t.Error("expected error for unknown service")
+	}
+}
+
+func TestRunIngressNames(t *testing.T) {
+	t.Parallel()
+
+	cfg := &model.ConfigRunServiceConfig{ //nolint:exhaustruct
+		Name: "svc",
+		Ports: []*model.ConfigRunServicePort{
+			{Port: 80, Type: "http", Publish: ptr(true), Ingresses: nil},
+			{Port: 8080, Type: "http", Publish: ptr(true), Ingresses: nil},
+			{Port: 9090, Type: "http", Publish: ptr(false), Ingresses: nil},
+			{Port: 5432, Type: "tcp", Publish: ptr(true), Ingresses: nil},
+		},
+	}
+
+	expected := map[uint16]string{80: "run-svc-80", 8080: "run-svc-8080"}
+	if diff := cmp.Diff(expected, RunIngressNames(cfg)); diff != "" {
+		t.Error(diff)
+	}
+
+	cfg.Ports = cfg.Ports[:1]
+
+	expected = map[uint16]string{80: "run-svc"}
+	if diff := cmp.Diff(expected, RunIngressNames(cfg)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRunDeploy(t *testing.T) {
+	t.Parallel()
+
+	cfg := &model.ConfigRunServiceConfig{ //nolint:exhaustruct
+		Name: "svc",
+		Resources: &model.ConfigRunServiceResources{
+			Compute: &model.ConfigComputeResources{
+				Cpu:    250,
+				Memory: 256,
+			},
+			Storage:    nil,
+			Replicas:   2,
+			Autoscaler: nil,
+		},
+	}
+
+	expected := &Deploy{
+		Replicas: 2,
+		Resources: &Resources{
+			Limits: &ResourceLimits{
+				CPUs:   "0.25",
+				Memory: "256M",
+			},
+			Reservations: nil,
+		},
+	}
+
+	if diff := cmp.Diff(expected, run(cfg, "dev", "branch", false, true).Deploy); diff != "" {
+		t.Error(diff)
+	}
+
+	if got := run(cfg, "dev", "branch", false, false).Deploy; got != nil {
+		t.Errorf("expected no deploy section without enforcing resources, got %v", got)
+	}
+}
@@ -0,0 +1,336 @@
+package dockercompose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RuntimeAuto    = "auto"
+	RuntimeDocker  = "docker"
+	RuntimePodman  = "podman"
+	RuntimeNerdctl = "nerdctl"
+)
+
+// Runtime hides the differences between the container engines we can drive.
+// All of them provide a `compose` subcommand compatible with docker's.
+type Runtime interface {
+	// Name returns the name of the runtime as accepted by --container-runtime.
+	Name() string
+	// Binary returns the CLI used to run containers and compose projects.
+	Binary() string
+	// SocketURL returns the docker compatible API traefik watches to discover
+	// services. It returns nil if the runtime doesn't provide one, in which
+	// case traefik's routes are written to a file instead.
+	SocketURL() (*url.URL, error)
+	// HostGateway returns the address used in extra hosts to reach the host.
+	HostGateway() string
+	// SupportsWait returns whether `compose up --wait` is supported.
+	SupportsWait() bool
+}
+
+// NewRuntime returns the runtime with the given name, any other name, including
+// "auto", detects the runtime from the environment.
+func NewRuntime(name string) Runtime {
+	switch name {
+	case RuntimeDocker:
+		return &DockerRuntime{}
+	case RuntimePodman:
+		return &PodmanRuntime{}
+	case RuntimeNerdctl:
+		return &NerdctlRuntime{}
+	default:
+		return DetectRuntime()
+	}
+}
+
+// DetectRuntime picks docker if it is installed unless DOCKER_HOST points to a
+// podman socket, then podman and then nerdctl. If none is found it falls back to
+// docker so the error users get mentions the most common runtime.
+func DetectRuntime() Runtime {
+	if strings.Contains(os.Getenv("DOCKER_HOST"), "podman") {
+		return &PodmanRuntime{}
+	}
+
+	switch {
+	case binaryExists(RuntimeDocker):
+		return &DockerRuntime{}
+	case binaryExists(RuntimePodman):
+		return &PodmanRuntime{}
+	case binaryExists(RuntimeNerdctl):
+		return &NerdctlRuntime{}
+	default:
+		return &DockerRuntime{}
+	}
+}
+
+func binaryExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func parseSocketURL(envVar, value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", envVar, err)
+	}
+
+	return u, nil
+}
+
+type DockerRuntime struct{}
+
+// dockerContextURL returns the endpoint of the active docker context, nil for
+// the default context. It differs from the default socket with i.e. colima,
+// OrbStack or rootless docker.
+func dockerContextURL() (*url.URL, error) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+
+		configDir = filepath.Join(home, ".docker")
+	}
+
+	name := os.Getenv("DOCKER_CONTEXT")
+	if name == "" {
+		b, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil //nolint:nilnil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read docker config: %w", err)
+		}
+
+		var config struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse docker config: %w", err)
+		}
+
+		name = config.CurrentContext
+	}
+
+	if name == "" || name == "default" {
+		return nil, nil //nolint:nilnil
+	}
+
+	// contexts are stored by the digest of their name
+	digest := sha256.Sum256([]byte(name))
+
+	b, err := os.ReadFile(
+		filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(digest[:]), "meta.json"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker context %s: %w", name, err)
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse docker context %s: %w", name, err)
+	}
+
+	host := meta.Endpoints["docker"].Host
+	if host == "" {
+		return nil, fmt.Errorf("docker context %s has no docker endpoint", name) //nolint:err113
+	}
+
+	return parseSocketURL("docker context "+name, host)
+}
+
+func (r *DockerRuntime) Name() string {
+	return RuntimeDocker
+}
+
+func (r *DockerRuntime) Binary() string {
+	return "docker"
+}
+
+func (r *DockerRuntime) SocketURL() (*url.URL, error) {
+	if socket, ok := os.LookupEnv("DOCKER_HOST"); ok {
+		return parseSocketURL("DOCKER_HOST", socket)
+	}
+
+	u, _ := url.Parse("unix:///var/run/docker.sock")
+
+	return u, nil
+}
+
+func (r *DockerRuntime) HostGateway() string {
+	return "host-gateway"
+}
+
+func (r *DockerRuntime) SupportsWait() bool {
+	return true
+}
+
+// PodmanRuntime supports both rootful and rootless podman. Podman's API is
+// docker compatible so traefik can watch it like docker's.
+type PodmanRuntime struct{}
+
+func (r *PodmanRuntime) Name() string {
+	return RuntimePodman
+}
+
+func (r *PodmanRuntime) Binary() string {
+	return "podman"
+}
+
+func (r *PodmanRuntime) SocketURL() (*url.URL, error) {
+	for _, envVar := range []string{"CONTAINER_HOST", "DOCKER_HOST"} {
+		if socket, ok := os.LookupEnv(envVar); ok {
+			return parseSocketURL(envVar, socket)
+		}
+	}
+
+	// rootless podman listens in the user's runtime dir
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" && os.Geteuid() != 0 {
+		socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+		if _, err := os.Stat(socket); err == nil {
+			return &url.URL{Scheme: "unix", Path: socket}, nil //nolint:exhaustruct
+		}
+	}
+
+	u, _ := url.Parse("unix:///run/podman/podman.sock")
+
+	return u, nil
+}
+
+// HostGateway returns host-gateway on podman 5.3 and newer, which resolve it to
+// the host's address. Older versions reject it so we use the address of the
+// host's default interface instead, which is reachable both from rootful
+// containers and from rootless ones using slirp4netns.
+func (r *PodmanRuntime) HostGateway() string {
+	out, err := exec.Command(
+		"podman", "version", "--format", "{{.Client.Version}}",
+	).Output()
+	if err == nil && versionAtLeast(strings.TrimSpace(string(out)), 5, 3) { //nolint:mnd
+		return "host-gateway"
+	}
+
+	// no packet is sent, dialing UDP only picks the outgoing interface
+	conn, err := net.Dial("udp", "192.0.2.1:9") //nolint:noctx
+	if err != nil {
+		return "host-gateway"
+	}
+	defer conn.Close()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return "host-gateway"
+	}
+
+	return addr.IP.String()
+}
+
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3) //nolint:mnd
+	if len(parts) < 2 {                                               //nolint:mnd
+		return false
+	}
+
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+
+	gotMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
+// SupportsWait depends on the compose provider podman delegates to, only
+// docker-compose supports --wait. Podman prefers docker-compose when it is
+// installed unless PODMAN_COMPOSE_PROVIDER says otherwise.
+func (r *PodmanRuntime) SupportsWait() bool {
+	if provider := os.Getenv("PODMAN_COMPOSE_PROVIDER"); provider != "" {
+		return strings.Contains(filepath.Base(provider), "docker-compose")
+	}
+
+	return binaryExists("docker-compose")
+}
+
+// NerdctlRuntime runs containers directly on containerd which doesn't provide a
+// docker compatible API, so traefik reads its routes from a file.
+type NerdctlRuntime struct{}
+
+func (r *NerdctlRuntime) Name() string {
+	return RuntimeNerdctl
+}
+
+func (r *NerdctlRuntime) Binary() string {
+	return "nerdctl"
+}
+
+func (r *NerdctlRuntime) SocketURL() (*url.URL, error) {
+	return nil, nil //nolint:nilnil
+}
+
+func (r *NerdctlRuntime) HostGateway() string {
+	return "host-gateway"
+}
+
+func (r *NerdctlRuntime) SupportsWait() bool {
+	return false
+}
+
+// adaptToRuntime replaces host-gateway in the extra hosts if the runtime needs a
+// different value and, when traefik can't discover the services by itself,
+// writes the routes it would have found in the services' labels.
+func adaptToRuntime(services map[string]*Service, runtime Runtime, dotNhostFolder string) error {
+	if gateway := runtime.HostGateway(); gateway != "host-gateway" {
+		for _, svc := range services {
+			for i, host := range svc.ExtraHosts {
+				if name, ok := strings.CutSuffix(host, ":host-gateway"); ok {
+					svc.ExtraHosts[i] = name + ":" + gateway
+				}
+			}
+		}
+	}
+
+	socket, err := runtime.SocketURL()
+	if err != nil {
+		return fmt.Errorf("failed to get %s socket: %w", runtime.Name(), err)
+	}
+
+	routesPath := filepath.Join(dotNhostFolder, "traefik", "routes.yaml")
+
+	if socket != nil {
+		if err := os.Remove(routesPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove traefik routes: %w", err)
+		}
+
+		return nil
+	}
+
+	b, err := yaml.Marshal(FileRoutes(services))
+	if err != nil {
+		return fmt.Errorf("failed to marshal traefik routes: %w", err)
+	}
+
+	return writeFile(routesPath, string(b))
+}
@@ -0,0 +1,304 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+type fakeRuntime struct {
+	NerdctlRuntime
+
+	gateway string
+}
+
+func (r *fakeRuntime) HostGateway() string {
+	return r.gateway
+}
+
+func TestFileRoutes(t *testing.T) {
+	t.Parallel()
+
+	services := map[string]*Service{
+		"storage": {Labels: Ingresses{ //nolint:exhaustruct
+			{
+				Name:    "storage",
+				TLS:     true,
+				Rule:    traefikHostMatch("storage"),
+				Port:    5000, //nolint:mnd
+				Rewrite: &Rewrite{Regex: "/v1(/|$$)(.*)", Replacement: "/$$2"},
+			},
+		}.Labels()},
+		"postgres": {Labels: nil}, //nolint:exhaustruct
+	}
+
+	expected := map[string]any{
+		"http": map[string]any{
+			"routers": map[string]map[string]any{
+				"storage": {
+					"entryPoints": []string{"web"},
+					"rule":        traefikHostMatch("storage"),
+					"service":     "storage",
+					"tls":         map[string]any{},
+					"middlewares": []string{"replace-storage"},
+				},
+			},
+			"services": map[string]any{
+				"storage": map[string]any{
+					"loadBalancer": map[string]any{
+						"servers": []map[string]string{{"url": "http://storage:5000"}},
+					},
+				},
+			},
+			"middlewares": map[string]any{
+				"replace-storage": map[string]any{
+					"replacePathRegex": map[string]string{
+						"regex":       "/v1(/|$$)(.*)",
+						"replacement": "/$$2",
+					},
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, FileRoutes(services)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestAdaptToRuntime(t *testing.T) {
+	t.Parallel()
+
+	dotNhostFolder := t.TempDir()
+
+	services := map[string]*Service{
+		"auth": { //nolint:exhaustruct
+			ExtraHosts: []string{"host.docker.internal:host-gateway", "example.com:10.0.0.1"},
+			Labels: Ingresses{
+				{Name: "auth", TLS: false, Rule: traefikHostMatch("auth"), Port: 4000, Rewrite: nil},
+			}.Labels(),
+		},
+	}
+
+	if err := adaptToRuntime(
+		services, &fakeRuntime{gateway: "192.168.1.10"}, dotNhostFolder, //nolint:exhaustruct
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedHosts := []string{"host.docker.internal:192.168.1.10", "example.com:10.0.0.1"}
+	if diff := cmp.Diff(expectedHosts, services["auth"].ExtraHosts); diff != "" {
+		t.Error(diff)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dotNhostFolder, "traefik", "routes.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes struct {
+		HTTP struct {
+			Services map[string]struct {
+				LoadBalancer struct {
+					Servers []struct {
+						URL string `yaml:"url"`
+					} `yaml:"servers"`
+				} `yaml:"loadBalancer"`
+			} `yaml:"services"`
+		} `yaml:"http"`
+	}
+	if err := yaml.Unmarshal(b, &routes); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := routes.HTTP.Services["auth"].LoadBalancer.Servers[0].URL; got != "http://auth:4000" {
+		t.Errorf("unexpected auth url in routes: %s", got)
+	}
+
+	// with a docker compatible API traefik reads the labels so the routes go away
+	if err := adaptToRuntime(services, &DockerRuntime{}, dotNhostFolder); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dotNhostFolder, "traefik", "routes.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected routes file to be removed, got: %v", err)
+	}
+}
+
+func TestTraefikRuntime(t *testing.T) {
+	t.Parallel()
+
+	svc, err := traefik("local", "myproject", 443, t.TempDir(), &NerdctlRuntime{}) //nolint:mnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"--api.insecure=true",
+		"--providers.file.directory=/opt/traefik",
+		"--providers.file.watch=true",
+		"--entrypoints.web.address=:443",
+	}
+	if diff := cmp.Diff(expected, svc.Command); diff != "" {
+		t.Error(diff)
+	}
+
+	if len(svc.Volumes) != 1 {
+		t.Errorf("expected no socket to be mounted, got: %v", svc.Volumes)
+	}
+}
+
+func TestContainersReady(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		statuses []ContainerStatus
+		ready    bool
+		err      bool
+	}{
+		{
+			name:     "no containers",
+			statuses: nil,
+			ready:    false,
+			err:      false,
+		},
+		{
+			name: "running and healthy",
+			statuses: []ContainerStatus{
+				{Name: "auth", State: "running", Health: "healthy"},     //nolint:exhaustruct
+				{Name: "traefik", State: "running", Health: ""},         //nolint:exhaustruct
+				{Name: "postgres", State: "running", Health: "healthy"}, //nolint:exhaustruct
+			},
+			ready: true,
+			err:   false,
+		},
+		{
+			name: "starting",
+			statuses: []ContainerStatus{
+				{Name: "auth", State: "running", Health: "starting"}, //nolint:exhaustruct
+				{Name: "storage", State: "created", Health: ""},      //nolint:exhaustruct
+			},
+			ready: false,
+			err:   false,
+		},
+		{
+			name: "unhealthy",
+			statuses: []ContainerStatus{
+				{Name: "auth", State: "running", Health: "unhealthy"}, //nolint:exhaustruct
+			},
+			ready: false,
+			err:   true,
+		},
+		{
+			name: "exited",
+			statuses: []ContainerStatus{
+				{Name: "auth", State: "exited", Health: ""}, //nolint:exhaustruct
+			},
+			ready: false,
+			err:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ready, err := containersReady(tc.statuses)
+			if ready != tc.ready || (err != nil) != tc.err {
+				t.Errorf("got ready %v and error %v", ready, err)
+			}
+		})
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	t.Parallel()
+
+	for version, expected := range map[string]bool{
+		"5.3.0":      true,
+		"5.4.1":      true,
+		"6.0.0":      true,
+		"v5.3.1":     true,
+		"5.2.5":      false,
+		"4.9.4":      false,
+		"4.9.4-dev":  false,
+		"garbage":    false,
+		"":           false,
+		"5.3.0-rc1":  true,
+		"10.0.0":     true,
+		"5.10.0":     true,
+		"5.1.10":     false,
+		"5":          false,
+		"5.x":        false,
+		"x.3":        false,
+		"5.3":        true,
+		"4.99.99-rc": false,
+	} {
+		if got := versionAtLeast(version, 5, 3); got != expected { //nolint:mnd
+			t.Errorf("versionAtLeast(%q) = %v, expected %v", version, got, expected)
+		}
+	}
+}
+
+func TestDockerRuntimeSocket(t *testing.T) { //nolint:paralleltest
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+
+	u, err := (&DockerRuntime{}).SocketURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(&url.URL{Scheme: "tcp", Host: "127.0.0.1:2375"}, u); diff != "" { //nolint:exhaustruct
+		t.Error(diff)
+	}
+}
+
+func TestDockerContextURL(t *testing.T) { //nolint:paralleltest
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+	t.Setenv("DOCKER_CONTEXT", "")
+
+	u, err := dockerContextURL()
+	if err != nil || u != nil {
+		t.Fatalf("expected no context without a docker config, got %v, %v", u, err)
+	}
+
+	// sha256 of "colima"
+	meta := filepath.Join(
+		configDir, "contexts", "meta",
+		"f24fd3749c1368328e2b149bec149cb6795619f244c5b584e844961215dadd16", "meta.json",
+	)
+	if err := writeFile(
+		meta,
+		`{"Name":"colima","Endpoints":{"docker":{"Host":"unix:///Users/me/.colima/default/docker.sock"}}}`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFile(
+		filepath.Join(configDir, "config.json"), `{"currentContext":"colima"}`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err = dockerContextURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &url.URL{Scheme: "unix", Path: "/Users/me/.colima/default/docker.sock"} //nolint:exhaustruct
+	if diff := cmp.Diff(expected, u); diff != "" {
+		t.Error(diff)
+	}
+
+	t.Setenv("DOCKER_CONTEXT", "default")
+
+	if u, err := dockerContextURL(); err != nil || u != nil {
+		t.Errorf("expected no endpoint for the default context, got %v, %v", u, err)
+	}
+}
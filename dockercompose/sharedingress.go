@@ -0,0 +1,375 @@
+package dockercompose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SharedIngressProject is the compose project of the shared ingress.
+	SharedIngressProject = "nhost-ingress"
+	// SharedIngressNetwork is the network the shared ingress reaches the
+	// services of the projects through.
+	SharedIngressNetwork = "nhost-ingress"
+
+	sharedIngressLabel     = "nhost.ingress"
+	sharedIngressPortLabel = "nhost.ingress.port"
+)
+
+// IngressRegistration is a project routed through the shared ingress.
+type IngressRegistration struct {
+	Project     string `json:"project"`
+	WorkingDir  string `json:"workingDir"`
+	ComposeFile string `json:"composeFile"`
+}
+
+type ingressRegistry struct {
+	HTTPPort uint                           `json:"httpPort"`
+	Projects map[string]IngressRegistration `json:"projects"`
+}
+
+// SharedIngress is a traefik managed by the CLI that routes to the
+// environments of several projects by subdomain so they can share the HTTP
+// port. Projects register themselves on `up` and unregister on `down`, the
+// ingress is stopped when the last one goes away.
+type SharedIngress struct {
+	runtime Runtime
+	folder  string
+}
+
+func NewSharedIngress(runtime Runtime, folder string) *SharedIngress {
+	return &SharedIngress{
+		runtime: runtime,
+		folder:  folder,
+	}
+}
+
+func (s *SharedIngress) DockerCompose() *DockerCompose {
+	return New(
+		s.runtime,
+		s.folder,
+		filepath.Join(s.folder, "docker-compose.yaml"),
+		SharedIngressProject,
+	)
+}
+
+// ComposeFile returns the compose file of the shared ingress listening on
+// httpPort. The ingress owns the network the projects join.
+func (s *SharedIngress) ComposeFile(httpPort uint) (*ComposeFile, error) {
+	socket, err := s.runtime.SocketURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s socket: %w", s.runtime.Name(), err)
+	}
+
+	if socket == nil {
+		return nil, fmt.Errorf( //nolint:err113
+			"the shared ingress discovers projects through the docker API which %s doesn't provide",
+			s.runtime.Name(),
+		)
+	}
+
+	if err := trafikFiles(s.folder); err != nil {
+		return nil, fmt.Errorf("failed to create traefik files: %w", err)
+	}
+
+	traefik, err := traefikService(
+		httpPort, s.folder, fmt.Sprintf("Label(`%s`,`true`)", sharedIngressLabel), s.runtime,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ComposeFile{
+		Services: map[string]*Service{"traefik": traefik},
+		Volumes:  nil,
+		Networks: map[string]*ComposeNetwork{
+			"default": {Name: SharedIngressNetwork, External: false},
+		},
+	}, nil
+}
+
+func (s *SharedIngress) registryPath() string {
+	return filepath.Join(s.folder, "projects.json")
+}
+
+func (s *SharedIngress) readRegistry() (*ingressRegistry, error) {
+	registry := &ingressRegistry{
+		HTTPPort: 0,
+		Projects: map[string]IngressRegistration{},
+	}
+
+	b, err := os.ReadFile(s.registryPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return registry, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared ingress registry: %w", err)
+	}
+
+	if err := json.Unmarshal(b, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse shared ingress registry: %w", err)
+	}
+
+	if registry.Projects == nil {
+		registry.Projects = map[string]IngressRegistration{}
+	}
+
+	return registry, nil
+}
+
+// lockRegistry serializes the changes to the registry of projects running
+// `nhost up` and `nhost down` at the same time. The returned file must be closed
+// to release the lock.
+func (s *SharedIngress) lockRegistry() (*os.File, error) {
+	if err := os.MkdirAll(s.folder, 0o755); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("failed to create folder for %s: %w", s.folder, err)
+	}
+
+	return lockFile(filepath.Join(s.folder, "projects.lock"))
+}
+
+// writeRegistry replaces the registry atomically so it is never read half
+// written.
+func (s *SharedIngress) writeRegistry(registry *ingressRegistry) error {
+	b, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared ingress registry: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.folder, "projects-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write shared ingress registry: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write shared ingress registry: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write shared ingress registry: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.registryPath()); err != nil {
+		return fmt.Errorf("failed to write shared ingress registry: %w", err)
+	}
+
+	return nil
+}
+
+// pruneRegistry drops the projects that aren't running anymore, i.e. they
+// were stopped without `nhost down`.
+func (s *SharedIngress) pruneRegistry(ctx context.Context, registry *ingressRegistry) {
+	maps.DeleteFunc(registry.Projects, func(_ string, r IngressRegistration) bool {
+		running, err := New(s.runtime, r.WorkingDir, r.ComposeFile, r.Project).
+			RunningServices(ctx)
+
+		return err != nil || len(running) == 0
+	})
+}
+
+// Register routes the subdomain to the project and starts the shared ingress
+// on httpPort if it isn't running. It fails if the subdomain belongs to another
+// running project or the ingress already listens on another port.
+func (s *SharedIngress) Register(
+	ctx context.Context, subdomain string, httpPort uint, registration IngressRegistration,
+) error {
+	lock, err := s.lockRegistry()
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	registry, err := s.readRegistry()
+	if err != nil {
+		return err
+	}
+
+	// a project can move to another subdomain
+	maps.DeleteFunc(registry.Projects, func(_ string, r IngressRegistration) bool {
+		return r.Project == registration.Project
+	})
+
+	s.pruneRegistry(ctx, registry)
+
+	if r, ok := registry.Projects[subdomain]; ok {
+		return fmt.Errorf( //nolint:err113
+			"subdomain %s is already used by project %s in %s, choose another one with --local-subdomain",
+			subdomain, r.Project, r.WorkingDir,
+		)
+	}
+
+	if len(registry.Projects) > 0 && registry.HTTPPort != httpPort {
+		return fmt.Errorf( //nolint:err113
+			"the shared ingress listens on port %d, start the environment with --http-port %d",
+			registry.HTTPPort, registry.HTTPPort,
+		)
+	}
+
+	composeFile, err := s.ComposeFile(httpPort)
+	if err != nil {
+		return err
+	}
+
+	dc := s.DockerCompose()
+	if err := dc.WriteComposeFile(composeFile); err != nil {
+		return err
+	}
+
+	if err := dc.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start shared ingress: %w", err)
+	}
+
+	registry.HTTPPort = httpPort
+	registry.Projects[subdomain] = registration
+
+	return s.writeRegistry(registry)
+}
+
+// Unregister removes the routes of the project and stops the shared ingress if
+// no other project uses it.
+func (s *SharedIngress) Unregister(ctx context.Context, project string) error {
+	lock, err := s.lockRegistry()
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	registry, err := s.readRegistry()
+	if err != nil {
+		return err
+	}
+
+	registered := len(registry.Projects)
+	maps.DeleteFunc(registry.Projects, func(_ string, r IngressRegistration) bool {
+		return r.Project == project
+	})
+
+	if len(registry.Projects) == registered {
+		return nil
+	}
+
+	s.pruneRegistry(ctx, registry)
+
+	if len(registry.Projects) == 0 {
+		if err := s.DockerCompose().Stop(ctx, false); err != nil {
+			return fmt.Errorf("failed to stop shared ingress: %w", err)
+		}
+	}
+
+	return s.writeRegistry(registry)
+}
+
+//nolint:gochecknoglobals
+var legacyHostRe = regexp.MustCompile(" \\|\\| Host\\(`local\\.[^`]+\\.nhost\\.run`\\)")
+
+// sharedIngressRule restricts the rule to the subdomain, the project's own
+// traefik matches any.
+func sharedIngressRule(rule, subdomain string) string {
+	// local.<service>.nhost.run is an alias of the "local" subdomain
+	if subdomain != "local" {
+		rule = legacyHostRe.ReplaceAllString(rule, "")
+	}
+
+	return strings.ReplaceAll(
+		rule, "HostRegexp(`^.+\\.", "HostRegexp(`^"+regexp.QuoteMeta(subdomain)+"\\.",
+	)
+}
+
+// sharedIngressLabels prefixes the names of the routers, services and
+// middlewares with the subdomain so they don't clash with the ones of other
+// projects in the shared ingress.
+func sharedIngressLabels(labels map[string]string, subdomain string, port string) map[string]string {
+	prefixed := make(map[string]string, len(labels))
+
+	for k, v := range labels {
+		rest, ok := strings.CutPrefix(k, "traefik.http.")
+		if !ok {
+			prefixed[k] = v
+			continue
+		}
+
+		kind, rest, _ := strings.Cut(rest, ".")
+		name, field, _ := strings.Cut(rest, ".")
+
+		switch {
+		case kind == "routers" && field == "rule":
+			v = sharedIngressRule(v, subdomain)
+		case kind == "routers" && field == "service":
+			v = subdomain + "-" + v
+		case kind == "routers" && field == "middlewares":
+			middlewares := strings.Split(v, ",")
+			for i, m := range middlewares {
+				middlewares[i] = subdomain + "-" + strings.TrimSpace(m)
+			}
+
+			v = strings.Join(middlewares, ",")
+		}
+
+		prefixed[fmt.Sprintf("traefik.http.%s.%s-%s.%s", kind, subdomain, name, field)] = v
+	}
+
+	prefixed[sharedIngressLabel] = "true"
+	prefixed[sharedIngressPortLabel] = port
+	prefixed["traefik.docker.network"] = SharedIngressNetwork
+
+	return prefixed
+}
+
+// UseSharedIngress replaces the project's traefik with the shared ingress. The
+// services with routes join the ingress network and their routes are limited
+// to the subdomain.
+func (c *ComposeFile) UseSharedIngress(subdomain string) error {
+	traefik, ok := c.Services["traefik"]
+	if !ok || len(traefik.Ports) == 0 {
+		return errors.New("traefik service not found") //nolint:err113
+	}
+
+	delete(c.Services, "traefik")
+
+	for _, svc := range c.Services {
+		if svc.Labels["traefik.enable"] != "true" {
+			continue
+		}
+
+		svc.Labels = sharedIngressLabels(svc.Labels, subdomain, traefik.Ports[0].Published)
+
+		if svc.Networks == nil {
+			svc.Networks = map[string]*Network{"default": nil}
+		}
+
+		svc.Networks[SharedIngressNetwork] = nil
+	}
+
+	if c.Networks == nil {
+		c.Networks = map[string]*ComposeNetwork{}
+	}
+
+	c.Networks[SharedIngressNetwork] = &ComposeNetwork{Name: SharedIngressNetwork, External: true}
+
+	return nil
+}
+
+// sharedIngressPort returns the port of the shared ingress the services are
+// routed through, if they are.
+func (c *ComposeFile) sharedIngressPort() (uint, bool) {
+	for _, svc := range c.Services {
+		if p, ok := svc.Labels[sharedIngressPortLabel]; ok {
+			port, err := strconv.ParseUint(p, 10, 32)
+			return uint(port), err == nil
+		}
+	}
+
+	return 0, false
+}
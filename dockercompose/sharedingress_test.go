@@ -0,0 +1,191 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUseSharedIngress(t *testing.T) {
+	t.Parallel()
+
+	composeFile := &ComposeFile{
+		Services: map[string]*Service{
+			"traefik": {Ports: ports(8443, 8443)}, //nolint:exhaustruct
+			"auth": { //nolint:exhaustruct
+				Labels: Ingresses{
+					{
+						Name:    "auth",
+						TLS:     true,
+						Rule:    traefikHostMatch("auth"),
+						Port:    4000, //nolint:mnd
+						Rewrite: &Rewrite{Regex: "/v1(/|$)(.*)", Replacement: "/$2"},
+					},
+				}.Labels(),
+			},
+			"sms": { //nolint:exhaustruct
+				Labels:   map[string]string{"traefik.enable": "true"},
+				Networks: map[string]*Network{"default": {Aliases: []string{"twilio"}}},
+			},
+			"postgres": {Ports: ports(5432, 5432)}, //nolint:exhaustruct
+		},
+		Volumes:  nil,
+		Networks: nil,
+	}
+
+	if err := composeFile.UseSharedIngress("myapp"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := composeFile.Services["traefik"]; ok {
+		t.Error("expected traefik to be removed")
+	}
+
+	expectedLabels := map[string]string{
+		"nhost.ingress":                                                            "true",
+		"nhost.ingress.port":                                                       "8443",
+		"traefik.docker.network":                                                   "nhost-ingress",
+		"traefik.enable":                                                           "true",
+		"traefik.http.routers.myapp-auth.entrypoints":                              "web",
+		"traefik.http.routers.myapp-auth.middlewares":                              "myapp-replace-auth",
+		"traefik.http.routers.myapp-auth.rule":                                     "(HostRegexp(`^myapp\\.auth\\.local\\.nhost\\.run$`))",
+		"traefik.http.routers.myapp-auth.service":                                  "myapp-auth",
+		"traefik.http.routers.myapp-auth.tls":                                      "true",
+		"traefik.http.services.myapp-auth.loadbalancer.server.port":                "4000",
+		"traefik.http.middlewares.myapp-replace-auth.replacepathregex.regex":       "/v1(/|$)(.*)",
+		"traefik.http.middlewares.myapp-replace-auth.replacepathregex.replacement": "/$2",
+	}
+	if diff := cmp.Diff(expectedLabels, composeFile.Services["auth"].Labels); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedNetworks := map[string]*Network{
+		"default":       {Aliases: []string{"twilio"}},
+		"nhost-ingress": nil,
+	}
+	if diff := cmp.Diff(expectedNetworks, composeFile.Services["sms"].Networks); diff != "" {
+		t.Error(diff)
+	}
+
+	if composeFile.Services["postgres"].Networks != nil {
+		t.Error("expected services without routes to stay out of the ingress network")
+	}
+
+	expectedComposeNetworks := map[string]*ComposeNetwork{
+		"nhost-ingress": {Name: "nhost-ingress", External: true},
+	}
+	if diff := cmp.Diff(expectedComposeNetworks, composeFile.Networks); diff != "" {
+		t.Error(diff)
+	}
+
+	port, useTLS, err := composeFile.Entrypoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if port != 8443 || !useTLS {
+		t.Errorf("unexpected entrypoint: %d %t", port, useTLS)
+	}
+
+	if diff := cmp.Diff([]string{"auth"}, composeFile.Services["auth"].IngressNames()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSharedIngressRule(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		subdomain string
+		rule      string
+		expected  string
+	}{
+		{
+			name:      "subdomain",
+			subdomain: "myapp",
+			rule:      traefikHostMatch("graphql") + "&& PathPrefix(`/v1`)",
+			expected:  "(HostRegexp(`^myapp\\.graphql\\.local\\.nhost\\.run$`))&& PathPrefix(`/v1`)",
+		},
+		{
+			name:      "local keeps its alias",
+			subdomain: "local",
+			rule:      traefikHostMatch("graphql"),
+			expected:  "(HostRegexp(`^local\\.graphql\\.local\\.nhost\\.run$`) || Host(`local.graphql.nhost.run`))",
+		},
+		{
+			name:      "run service fqdn",
+			subdomain: "myapp",
+			rule:      traefikHostMatch("api") + " || Host(`api.example.com`)",
+			expected:  "(HostRegexp(`^myapp\\.api\\.local\\.nhost\\.run$`)) || Host(`api.example.com`)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, sharedIngressRule(tc.rule, tc.subdomain)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSharedIngressRegistryLock(t *testing.T) {
+	t.Parallel()
+
+	s := NewSharedIngress(nil, filepath.Join(t.TempDir(), "ingress"))
+
+	lock, err := s.lockRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		second, err := s.lockRegistry()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer second.Close()
+
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected the registry to stay locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	registry := &ingressRegistry{
+		HTTPPort: 443,
+		Projects: map[string]IngressRegistration{
+			"app": {Project: "app", WorkingDir: "/app", ComposeFile: "/app/.nhost/docker-compose.yaml"},
+		},
+	}
+	if err := s.writeRegistry(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Close()
+
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the registry to be unlocked")
+	}
+
+	got, err := s.readRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(registry, got); diff != "" {
+		t.Error(diff)
+	}
+}
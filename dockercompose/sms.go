@@ -0,0 +1,167 @@
+package dockercompose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nhost/be/services/mimir/model"
+	"github.com/nhost/cli/ssl"
+)
+
+const smsCatcherPort = 8089
+
+const (
+	fakeTwilioAccountSid         = "AC00000000000000000000000000000000"
+	fakeTwilioAuthToken          = "00000000000000000000000000000000"
+	fakeTwilioMessagingServiceID = "MG00000000000000000000000000000000"
+)
+
+//nolint:gochecknoglobals
+var twilioHosts = []string{"api.twilio.com", "verify.twilio.com"}
+
+func smsEnabled(cfg *model.ConfigConfig) bool {
+	return deptr(cfg.GetAuth().GetMethod().GetSmsPasswordless().GetEnabled())
+}
+
+// smsNetwork is only joined by auth and the sms catcher so no other service
+// resolves twilio's hosts to the catcher.
+const smsNetwork = "sms"
+
+// smsCatcherFiles issues a certificate for twilio's hosts signed by a CA of the
+// project that is constrained to those hosts and only trusted by auth. When a
+// local domain is used its CA is added to the bundle auth trusts so auth can
+// still reach the other services.
+func smsCatcherFiles(dotNhostFolder string, localDomain *LocalDomain) (string, error) {
+	dir := filepath.Join(dotNhostFolder, "sms")
+
+	ca, err := ssl.LoadOrCreateCA(filepath.Join(dir, "ca"), twilioHosts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to load sms catcher CA: %w", err)
+	}
+
+	certPEM, keyPEM, err := ca.IssueCert(twilioHosts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to issue certificate for sms catcher: %w", err)
+	}
+
+	bundle, err := os.ReadFile(ca.CertPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read sms catcher CA: %w", err)
+	}
+
+	if localDomain != nil {
+		localCA, err := ssl.LoadOrCreateCA(localDomain.CAFolder, localDomain.Domain)
+		if err != nil {
+			return "", fmt.Errorf("failed to load local CA: %w", err)
+		}
+
+		localPEM, err := os.ReadFile(localCA.CertPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read local CA: %w", err)
+		}
+
+		bundle = append(bundle, localPEM...)
+	}
+
+	for _, f := range []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{name: "tls.crt", data: certPEM, perm: 0o644}, //nolint:mnd
+		{name: "tls.key", data: keyPEM, perm: 0o600},  //nolint:mnd
+		{name: "ca.crt", data: bundle, perm: 0o644},   //nolint:mnd
+	} {
+		if err := os.WriteFile(filepath.Join(dir, f.name), f.data, f.perm); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	return dir, nil
+}
+
+// smsCatcher runs a fake twilio API that auth reaches instead of the real one
+// thanks to the aliases in the sms network. Only the certificate is mounted so
+// the CA's key stays out of the container.
+func smsCatcher(image string, useTLS bool, projectName, dir string) *Service {
+	labels := Ingresses{
+		{
+			Name:    "sms",
+			TLS:     useTLS,
+			Rule:    traefikHostMatch("dashboard") + "&& PathPrefix(`/v1/sms`)",
+			Port:    smsCatcherPort,
+			Rewrite: nil,
+		},
+	}.Labels()
+	// traefik can't tell which of the networks to use otherwise
+	labels["traefik.docker.network"] = projectName + "_default"
+
+	return &Service{
+		Image:       image,
+		DependsOn:   nil,
+		EntryPoint:  []string{},
+		Command:     []string{"sms-catcher"},
+		Environment: nil,
+		ExtraHosts:  []string{},
+		HealthCheck: nil,
+		Labels:      labels,
+		Ports:       nil,
+		Restart:     "always",
+		Volumes: []Volume{
+			{
+				Type:     "bind",
+				Source:   filepath.Join(dir, "tls.crt"),
+				Target:   "/opt/sms/tls.crt",
+				ReadOnly: ptr(true),
+			},
+			{
+				Type:     "bind",
+				Source:   filepath.Join(dir, "tls.key"),
+				Target:   "/opt/sms/tls.key",
+				ReadOnly: ptr(true),
+			},
+		},
+		WorkingDir: nil,
+		Deploy:     nil,
+		Networks: map[string]*Network{
+			"default":  nil,
+			smsNetwork: {Aliases: twilioHosts},
+		},
+	}
+}
+
+// smsAuthPatch points auth to the sms catcher whose files are in dir. The
+// twilio credentials are replaced so real ones are never used locally and the
+// catcher's CA is trusted so auth accepts its certificate.
+func smsAuthPatch(auth *Service, projectName, dir string) {
+	auth.Environment["AUTH_SMS_PROVIDER"] = "twilio"
+	auth.Environment["AUTH_SMS_TWILIO_ACCOUNT_SID"] = fakeTwilioAccountSid
+	auth.Environment["AUTH_SMS_TWILIO_AUTH_TOKEN"] = fakeTwilioAuthToken
+
+	// keep the configured service so auth uses the same twilio API as in the cloud
+	if auth.Environment["AUTH_SMS_TWILIO_MESSAGING_SERVICE_ID"] == "" {
+		auth.Environment["AUTH_SMS_TWILIO_MESSAGING_SERVICE_ID"] = fakeTwilioMessagingServiceID
+	}
+
+	trustCA(auth, filepath.Join(dir, "ca.crt"))
+
+	if auth.DependsOn == nil {
+		auth.DependsOn = map[string]DependsOn{}
+	}
+
+	auth.DependsOn["sms"] = DependsOn{Condition: "service_started"}
+
+	if auth.Networks == nil {
+		auth.Networks = map[string]*Network{"default": nil}
+	}
+
+	auth.Networks[smsNetwork] = nil
+
+	// like the catcher, auth is now in two networks and traefik can't reach the sms one
+	if auth.Labels == nil {
+		auth.Labels = map[string]string{}
+	}
+
+	auth.Labels["traefik.docker.network"] = projectName + "_default"
+}
@@ -0,0 +1,139 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSMSAuthPatch(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{ //nolint:exhaustruct
+		Environment: map[string]string{
+			"AUTH_SMS_PROVIDER":                    "twilio",
+			"AUTH_SMS_TWILIO_ACCOUNT_SID":          "ACrealsid",
+			"AUTH_SMS_TWILIO_AUTH_TOKEN":           "realtoken",
+			"AUTH_SMS_TWILIO_MESSAGING_SERVICE_ID": "VAverifyservice",
+		},
+	}
+
+	smsAuthPatch(svc, "myproject", "/project/.nhost/sms")
+
+	expectedEnv := map[string]string{
+		"AUTH_SMS_PROVIDER":                    "twilio",
+		"AUTH_SMS_TWILIO_ACCOUNT_SID":          fakeTwilioAccountSid,
+		"AUTH_SMS_TWILIO_AUTH_TOKEN":           fakeTwilioAuthToken,
+		"AUTH_SMS_TWILIO_MESSAGING_SERVICE_ID": "VAverifyservice",
+		"NODE_EXTRA_CA_CERTS":                  "/opt/nhost/ca/ca.crt",
+		"SSL_CERT_DIR":                         "/etc/ssl/certs:/opt/nhost/ca",
+	}
+	if diff := cmp.Diff(expectedEnv, svc.Environment); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedVolumes := []Volume{
+		{
+			Type:     "bind",
+			Source:   filepath.Join("/project/.nhost/sms", "ca.crt"),
+			Target:   "/opt/nhost/ca/ca.crt",
+			ReadOnly: ptr(true),
+		},
+	}
+	if diff := cmp.Diff(expectedVolumes, svc.Volumes); diff != "" {
+		t.Error(diff)
+	}
+
+	if svc.DependsOn["sms"].Condition != "service_started" {
+		t.Errorf("expected auth to depend on sms, got %v", svc.DependsOn)
+	}
+
+	if diff := cmp.Diff(
+		map[string]*Network{"default": nil, "sms": nil},
+		svc.Networks,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff("myproject_default", svc.Labels["traefik.docker.network"]); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSMSCatcher(t *testing.T) {
+	t.Parallel()
+
+	svc := smsCatcher("nhost/cli:1.0.0", true, "myproject", "/project/.nhost/sms")
+
+	if diff := cmp.Diff(
+		map[string]*Network{
+			"default": nil,
+			"sms":     {Aliases: []string{"api.twilio.com", "verify.twilio.com"}},
+		},
+		svc.Networks,
+	); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff("myproject_default", svc.Labels["traefik.docker.network"]); diff != "" {
+		t.Error(diff)
+	}
+
+	for _, v := range svc.Volumes {
+		if v.Source == "/project/.nhost/sms" || filepath.Dir(v.Source) != "/project/.nhost/sms" {
+			t.Errorf("expected only the certificate files to be mounted, got %s", v.Source)
+		}
+	}
+}
+
+func TestSMSCatcherFiles(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		localDomain bool
+		expectedCAs int
+	}{
+		{name: "default domain", localDomain: false, expectedCAs: 1},
+		{name: "local domain", localDomain: true, expectedCAs: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpdir := t.TempDir()
+
+			var localDomain *LocalDomain
+			if tc.localDomain {
+				localDomain = &LocalDomain{Domain: "nhost.test", CAFolder: t.TempDir()}
+			}
+
+			dir, err := smsCatcherFiles(tmpdir, localDomain)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, "tls.key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != 0o600 {
+				t.Errorf("expected the key to be private, got %s", info.Mode().Perm())
+			}
+
+			bundle, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := bytes.Count(bundle, []byte("BEGIN CERTIFICATE")); got != tc.expectedCAs {
+				t.Errorf("expected %d CAs in the bundle, got %d", tc.expectedCAs, got)
+			}
+		})
+	}
+}
@@ -15,6 +15,23 @@ func deptr[T any](t *T) T { //nolint:ireturn
 	return *t
 }
 
+// cloudAntivirusServer is the server the cloud sets by default, it points to a
+// run service that only exists in the cloud.
+const cloudAntivirusServer = "tcp://run-clamav:3310"
+
+// localAntivirus returns true if storage should use the clamav we run locally,
+// i.e. when the antivirus is enabled with the cloud's default server. Other
+// servers, i.e. a clamd run service, are used as configured.
+func localAntivirus(cfg *model.ConfigConfig) bool {
+	if cfg.GetStorage().GetAntivirus() == nil {
+		return false
+	}
+
+	server := deptr(cfg.GetStorage().GetAntivirus().GetServer())
+
+	return server == "" || server == cloudAntivirusServer
+}
+
 func storage( //nolint:funlen
 	cfg *model.ConfigConfig,
 	subdomain string,
@@ -26,6 +43,11 @@ func storage( //nolint:funlen
 		httpPort = exposePort
 	}
 
+	antivirusServer := deptr(cfg.GetStorage().GetAntivirus().GetServer())
+	if localAntivirus(cfg) {
+		antivirusServer = fmt.Sprintf("tcp://clamav:%d", clamavPort)
+	}
+
 	envars, err := appconfig.HasuraStorageEnv(
 		cfg,
 		"http://graphql:8080/v1",
@@ -37,7 +59,7 @@ func storage( //nolint:funlen
 		"",
 		"minioaccesskey123123",
 		"minioaccesskey123123",
-		deptr(cfg.Storage.GetAntivirus().GetServer()),
+		antivirusServer,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get storage env vars: %w", err)
@@ -48,19 +70,27 @@ func storage( //nolint:funlen
 		env[v.Name] = v.Value
 	}
 
-	return &Service{
-		Image: "nhost/hasura-storage:" + *cfg.GetStorage().GetVersion(),
-		DependsOn: map[string]DependsOn{
-			"minio": {
-				Condition: "service_started",
-			},
-			"graphql": {
-				Condition: "service_healthy",
-			},
-			"postgres": {
-				Condition: "service_healthy",
-			},
+	dependsOn := map[string]DependsOn{
+		"minio": {
+			Condition: "service_started",
+		},
+		"graphql": {
+			Condition: "service_healthy",
+		},
+		"postgres": {
+			Condition: "service_healthy",
 		},
+	}
+
+	if localAntivirus(cfg) {
+		dependsOn["clamav"] = DependsOn{
+			Condition: "service_healthy",
+		}
+	}
+
+	return &Service{
+		Image:      "nhost/hasura-storage:" + *cfg.GetStorage().GetVersion(),
+		DependsOn:  dependsOn,
 		EntryPoint: nil,
 		Command: []string{
 			"serve",
@@ -81,5 +111,39 @@ func storage( //nolint:funlen
 		HealthCheck: nil,
 		Volumes:     nil,
 		WorkingDir:  nil,
+		Deploy:      nil,
+		Networks:    nil,
 	}, nil
 }
+
+func clamav(subdomain string) *Service {
+	return &Service{
+		Image:       "clamav/clamav:1.3",
+		DependsOn:   nil,
+		EntryPoint:  nil,
+		Command:     nil,
+		Environment: nil,
+		ExtraHosts:  extraHosts(subdomain),
+		// signatures are downloaded on first start, which can take a while
+		HealthCheck: &HealthCheck{
+			Test:        []string{"CMD", "clamdcheck.sh"},
+			Timeout:     "10s",
+			Interval:    "10s",
+			StartPeriod: "600s",
+		},
+		Labels:  nil,
+		Ports:   nil,
+		Restart: "always",
+		Volumes: []Volume{
+			{
+				Type:     "volume",
+				Source:   "clamav",
+				Target:   "/var/lib/clamav",
+				ReadOnly: ptr(false),
+			},
+		},
+		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
+	}
+}
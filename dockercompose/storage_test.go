@@ -11,6 +11,7 @@ func expectedStorage() *Service {
 	return &Service{
 		Image: "nhost/hasura-storage:0.2.5",
 		DependsOn: map[string]DependsOn{
+			"clamav":   {Condition: "service_healthy"},
 			"graphql":  {Condition: "service_healthy"},
 			"minio":    {Condition: "service_started"},
 			"postgres": {Condition: "service_healthy"},
@@ -31,7 +32,7 @@ func expectedStorage() *Service {
 			"S3_REGION":                   "",
 			"S3_ROOT_FOLDER":              "",
 			"S3_SECRET_KEY":               "minioaccesskey123123",
-			"CLAMAV_SERVER":               "tcp://run-clamav:3310",
+			"CLAMAV_SERVER":               "tcp://clamav:3310",
 		},
 		ExtraHosts: []string{
 			"host.docker.internal:host-gateway",
@@ -61,6 +62,8 @@ func expectedStorage() *Service {
 		Restart:    "always",
 		Volumes:    nil,
 		WorkingDir: nil,
+		Deploy:     nil,
+		Networks:   nil,
 	}
 }
 
@@ -99,6 +102,42 @@ func TestStorage(t *testing.T) {
 					},
 				}
 
+				return svc
+			},
+		},
+		{
+			name: "without antivirus",
+			cfg: func() *model.ConfigConfig {
+				cfg := getConfig()
+				cfg.Storage.Antivirus = nil
+
+				return cfg
+			},
+			useTlS:     false,
+			exposePort: 0,
+			expected: func() *Service {
+				svc := expectedStorage()
+				delete(svc.DependsOn, "clamav")
+				delete(svc.Environment, "CLAMAV_SERVER")
+
+				return svc
+			},
+		},
+		{
+			name: "custom antivirus server",
+			cfg: func() *model.ConfigConfig {
+				cfg := getConfig()
+				cfg.Storage.Antivirus.Server = ptr("tcp://run-clamd:3310")
+
+				return cfg
+			},
+			useTlS:     false,
+			exposePort: 0,
+			expected: func() *Service {
+				svc := expectedStorage()
+				delete(svc.DependsOn, "clamav")
+				svc.Environment["CLAMAV_SERVER"] = "tcp://run-clamd:3310"
+
 				return svc
 			},
 		},
@@ -3,10 +3,15 @@ package dockercompose
 import "fmt"
 
 func URL(host, service string, port uint, useTLS bool) string {
+	return LocalURL(DefaultLocalDomain, host, service, port, useTLS)
+}
+
+// LocalURL is like URL for environments served at a custom local domain.
+func LocalURL(domain, host, service string, port uint, useTLS bool) string {
 	if useTLS && port == 443 {
-		return fmt.Sprintf("https://%s.%s.local.nhost.run", host, service)
+		return fmt.Sprintf("https://%s.%s.%s", host, service, domain)
 	} else if !useTLS && port == 80 {
-		return fmt.Sprintf("http://%s.%s.local.nhost.run", host, service)
+		return fmt.Sprintf("http://%s.%s.%s", host, service, domain)
 	}
 
 	protocol := schemeHTTP
@@ -14,5 +19,5 @@ func URL(host, service string, port uint, useTLS bool) string {
 		protocol = schemeHTTPS
 	}
 
-	return fmt.Sprintf("%s://%s.%s.local.nhost.run:%d", protocol, host, service, port)
+	return fmt.Sprintf("%s://%s.%s.%s:%d", protocol, host, service, domain, port)
 }
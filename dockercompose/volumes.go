@@ -0,0 +1,222 @@
+package dockercompose
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+const (
+	labelComposeProject = "com.docker.compose.project"
+	labelComposeVolume  = "com.docker.compose.volume"
+	labelComposeService = "com.docker.compose.service"
+)
+
+const volumeCopyImage = "alpine:3"
+
+// BranchVolume is a docker volume created by docker compose for a given branch.
+type BranchVolume struct {
+	// Name of the volume in docker, docker compose prefixes it with the project name
+	Name string
+	// Volume is the name of the volume in the compose file
+	Volume string
+	Branch string
+	Kind   string
+	prefix string
+	suffix string
+	// alternatives are the possible ways to split an ambiguous volume name into
+	// a branch and a kind, see ParseBranchVolume
+	alternatives []BranchVolume
+}
+
+// ForBranch returns the name the volume would have in the compose file of branch.
+func (v BranchVolume) ForBranch(branch string) string {
+	return v.prefix + sanitizeBranch(branch) + v.suffix
+}
+
+// InBranch returns the volume as belonging to branch if its name allows it.
+// Ambiguous volumes are resolved this way.
+func (v BranchVolume) InBranch(branch string) (BranchVolume, bool) {
+	branch = sanitizeBranch(branch)
+
+	for _, alt := range v.alternatives {
+		if alt.Branch == branch {
+			return alt, true
+		}
+	}
+
+	return v, v.Branch == branch
+}
+
+// ParseBranchVolume maps a volume name from the compose file back to the branch
+// it belongs to. See getServices and runVolumeName for the naming scheme.
+//
+// Run service volumes can't always be told apart, i.e. fix-run-script-run-svc-data
+// belongs either to branch fix or to branch fix-run-script. The first split is
+// returned and the rest are kept for InBranch.
+func ParseBranchVolume(name, volume string) (BranchVolume, bool) {
+	v := BranchVolume{
+		Name:         name,
+		Volume:       volume,
+		Branch:       "",
+		Kind:         "",
+		prefix:       "",
+		suffix:       "",
+		alternatives: nil,
+	}
+
+	for _, kind := range []string{"pgdata", "minio", "mailhog", "grafana"} {
+		if branch, ok := strings.CutPrefix(volume, kind+"_"); ok {
+			v.Branch, v.Kind, v.prefix = branch, kind, kind+"_"
+			return v, branch != ""
+		}
+	}
+
+	for _, kind := range []string{"root_node_modules", "functions_node_modules"} {
+		if branch, ok := strings.CutSuffix(volume, "-"+kind); ok {
+			v.Branch, v.Kind, v.suffix = branch, kind, "-"+kind
+			return v, branch != ""
+		}
+	}
+
+	alternatives := make([]BranchVolume, 0)
+
+	for offset := 0; ; {
+		i := strings.Index(volume[offset:], "-run-")
+		if i < 0 {
+			break
+		}
+
+		i += offset
+		offset = i + 1
+
+		if i == 0 {
+			continue
+		}
+
+		alt := v
+		alt.Branch, alt.Kind, alt.suffix = volume[:i], volume[i+1:], volume[i:]
+		alternatives = append(alternatives, alt)
+	}
+
+	switch len(alternatives) {
+	case 0:
+		return v, false
+	case 1:
+		return alternatives[0], true
+	default:
+		v = alternatives[0]
+		v.alternatives = alternatives
+
+		return v, true
+	}
+}
+
+// StaleVolumes returns the volumes that don't belong to any of the branches.
+// Ambiguous volumes are only stale if none of the branches they may belong to
+// exist.
+func StaleVolumes(volumes []BranchVolume, branches []string) []BranchVolume {
+	stale := make([]BranchVolume, 0)
+	for _, v := range volumes {
+		if !slices.ContainsFunc(branches, func(b string) bool {
+			_, ok := v.InBranch(b)
+			return ok
+		}) {
+			stale = append(stale, v)
+		}
+	}
+
+	return stale
+}
+
+func (d *Docker) VolumeList(ctx context.Context, projectName string) ([]BranchVolume, error) {
+	cmd := exec.CommandContext( //nolint:gosec
+		ctx,
+		d.runtime.Binary(), "volume", "ls",
+		"--filter", "label="+labelComposeProject+"="+projectName,
+		"--format", `{{.Name}}	{{.Label "`+labelComposeVolume+`"}}`,
+	)
+	cmd.Stderr = os.Stderr
+
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	volumes := make([]BranchVolume, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		name, volume, _ := strings.Cut(scanner.Text(), "\t")
+		if v, ok := ParseBranchVolume(name, volume); ok {
+			volumes = append(volumes, v)
+		}
+	}
+
+	slices.SortFunc(volumes, func(a, b BranchVolume) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return volumes, nil
+}
+
+func (d *Docker) VolumeExists(ctx context.Context, name string) bool {
+	cmd := exec.CommandContext(ctx, d.runtime.Binary(), "volume", "inspect", name) //nolint:gosec
+	return cmd.Run() == nil
+}
+
+// VolumeCopy copies the contents of src into dst, replacing whatever dst had.
+// dst is created with the labels docker compose expects so it adopts it as
+// its own when the environment starts.
+func (d *Docker) VolumeCopy(ctx context.Context, projectName, src, dst, dstVolume string) error {
+	create := exec.CommandContext( //nolint:gosec
+		ctx,
+		d.runtime.Binary(), "volume", "create",
+		"--label", labelComposeProject+"="+projectName,
+		"--label", labelComposeVolume+"="+dstVolume,
+		dst,
+	)
+	create.Stderr = os.Stderr
+
+	if err := create.Run(); err != nil {
+		return fmt.Errorf("failed to create volume %s: %w", dst, err)
+	}
+
+	cmd := exec.CommandContext( //nolint:gosec
+		ctx,
+		d.runtime.Binary(), "run", "--rm",
+		"-v", src+":/from:ro",
+		"-v", dst+":/to",
+		volumeCopyImage,
+		"sh", "-c", "find /to -mindepth 1 -delete && cp -a /from/. /to/",
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy volume %s to %s: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+func (d *Docker) VolumeRemove(ctx context.Context, names ...string) error {
+	cmd := exec.CommandContext( //nolint:gosec
+		ctx,
+		d.runtime.Binary(),
+		append([]string{"volume", "rm"}, names...)...,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove volumes: %w", err)
+	}
+
+	return nil
+}
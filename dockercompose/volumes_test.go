@@ -0,0 +1,139 @@
+package dockercompose //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseBranchVolume(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		volume    string
+		ok        bool
+		branch    string
+		kind      string
+		forBranch string
+	}{
+		{
+			volume:    "pgdata_main",
+			ok:        true,
+			branch:    "main",
+			kind:      "pgdata",
+			forBranch: "pgdata_feature-x",
+		},
+		{
+			volume:    "mailhog_feature-y",
+			ok:        true,
+			branch:    "feature-y",
+			kind:      "mailhog",
+			forBranch: "mailhog_feature-x",
+		},
+		{
+			volume:    "main-root_node_modules",
+			ok:        true,
+			branch:    "main",
+			kind:      "root_node_modules",
+			forBranch: "feature-x-root_node_modules",
+		},
+		{
+			volume:    "main-run-myservice-data",
+			ok:        true,
+			branch:    "main",
+			kind:      "run-myservice-data",
+			forBranch: "feature-x-run-myservice-data",
+		},
+		{
+			volume:    "something_else",
+			ok:        false,
+			branch:    "",
+			kind:      "",
+			forBranch: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.volume, func(t *testing.T) {
+			t.Parallel()
+
+			v, ok := ParseBranchVolume("project_"+tc.volume, tc.volume)
+			if ok != tc.ok {
+				t.Fatalf("expected ok=%v, got %v", tc.ok, ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if v.Branch != tc.branch || v.Kind != tc.kind {
+				t.Errorf("expected %s/%s, got %s/%s", tc.branch, tc.kind, v.Branch, v.Kind)
+			}
+
+			if got := v.ForBranch("Feature-X"); got != tc.forBranch {
+				t.Errorf("expected %s, got %s", tc.forBranch, got)
+			}
+		})
+	}
+}
+
+func TestStaleVolumes(t *testing.T) {
+	t.Parallel()
+
+	volumes := make([]BranchVolume, 0)
+	for _, name := range []string{
+		"pgdata_main",
+		"pgdata_featurex",
+		"minio_old",
+		"old-root_node_modules",
+		"fix-run-script-run-svc-data",
+		"gone-run-script-run-svc-data",
+	} {
+		v, _ := ParseBranchVolume("project_"+name, name)
+		volumes = append(volumes, v)
+	}
+
+	stale := StaleVolumes(volumes, []string{"main", "feature/x", "fix-run-script"})
+
+	got := make([]string, len(stale))
+	for i, v := range stale {
+		got[i] = v.Volume
+	}
+
+	expected := []string{"minio_old", "old-root_node_modules", "gone-run-script-run-svc-data"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestBranchVolumeAmbiguous(t *testing.T) {
+	t.Parallel()
+
+	v, ok := ParseBranchVolume("project_fix-run-script-run-svc-data", "fix-run-script-run-svc-data")
+	if !ok {
+		t.Fatal("expected volume to be parsed")
+	}
+
+	for branch, kind := range map[string]string{
+		"fix":            "run-script-run-svc-data",
+		"fix-run-script": "run-svc-data",
+	} {
+		got, ok := v.InBranch(branch)
+		if !ok {
+			t.Fatalf("expected volume to belong to branch %s", branch)
+		}
+
+		if got.Kind != kind {
+			t.Errorf("expected kind %s for branch %s, got %s", kind, branch, got.Kind)
+		}
+	}
+
+	got, _ := v.InBranch("fix-run-script")
+	if diff := cmp.Diff("feature-x-run-svc-data", got.ForBranch("Feature-X")); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, ok := v.InBranch("main"); ok {
+		t.Error("expected volume not to belong to branch main")
+	}
+}
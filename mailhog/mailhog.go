@@ -0,0 +1,140 @@
+//nolint:tagliatelle
+package mailhog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Path struct {
+	Mailbox string `json:"Mailbox"`
+	Domain  string `json:"Domain"`
+}
+
+func (p Path) String() string {
+	return p.Mailbox + "@" + p.Domain
+}
+
+type Raw struct {
+	From string   `json:"From"`
+	To   []string `json:"To"`
+	Data string   `json:"Data"`
+}
+
+type rawMessage struct {
+	ID      string    `json:"ID"`
+	From    Path      `json:"From"`
+	To      []Path    `json:"To"`
+	Created time.Time `json:"Created"`
+	Raw     Raw       `json:"Raw"`
+}
+
+type messages struct {
+	Total int          `json:"total"`
+	Count int          `json:"count"`
+	Start int          `json:"start"`
+	Items []rawMessage `json:"items"`
+}
+
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// New returns a client for the mailhog API. baseURL is the URL of the mailhog UI.
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second}, //nolint:exhaustruct,mnd
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query mailhog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf( //nolint:err113
+			"mailhog returned status code (%d): %s", resp.StatusCode, string(b),
+		)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+func parseMessages(items []rawMessage) ([]*Message, error) {
+	msgs := make([]*Message, 0, len(items))
+	for _, item := range items {
+		msg, err := parseMessage(item)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+// List returns the most recent messages first. If to is not empty only messages
+// sent to that address are returned.
+func (c *Client) List(ctx context.Context, to string, limit int) ([]*Message, error) {
+	query := url.Values{}
+	query.Set("start", "0")
+	query.Set("limit", fmt.Sprint(limit))
+
+	path := "/api/v2/messages"
+	if to != "" {
+		path = "/api/v2/search"
+		query.Set("kind", "to")
+		query.Set("query", to)
+	}
+
+	var resp messages
+	if err := c.get(ctx, path, query, &resp); err != nil {
+		return nil, err
+	}
+
+	return parseMessages(resp.Items)
+}
+
+func (c *Client) Get(ctx context.Context, id string) (*Message, error) {
+	var resp rawMessage
+	if err := c.get(ctx, "/api/v1/messages/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return parseMessage(resp)
+}
@@ -0,0 +1,163 @@
+package mailhog
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("message not found")
+
+type Message struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      []string  `json:"to"`
+	Subject string    `json:"subject"`
+	Created time.Time `json:"created"`
+	Text    string    `json:"text,omitempty"`
+	HTML    string    `json:"html,omitempty"`
+	Links   []string  `json:"links"`
+}
+
+func decodeBody(r io.Reader, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode body: %w", err)
+	}
+
+	return b, nil
+}
+
+func (m *Message) addPart(contentType, encoding string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to read multipart body: %w", err)
+			}
+
+			if err := m.addPart(
+				part.Header.Get("Content-Type"),
+				part.Header.Get("Content-Transfer-Encoding"),
+				part,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := decodeBody(body, encoding)
+	if err != nil {
+		return err
+	}
+
+	switch mediaType {
+	case "text/plain":
+		m.Text += string(b)
+	case "text/html":
+		m.HTML += string(b)
+	}
+
+	return nil
+}
+
+//nolint:gochecknoglobals
+var (
+	hrefRe = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	linkRe = regexp.MustCompile(`https?://[^\s"'<>]+`)
+)
+
+// extractLinks returns the links found in the message, links in html anchors
+// come first as those are the ones the user clicks on.
+func extractLinks(text, htmlBody string) []string {
+	links := make([]string, 0)
+
+	add := func(link string) {
+		link = html.UnescapeString(link)
+		if strings.HasPrefix(link, "http") && !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+
+	for _, m := range hrefRe.FindAllStringSubmatch(htmlBody, -1) {
+		add(m[1])
+	}
+
+	for _, m := range linkRe.FindAllString(text, -1) {
+		add(m)
+	}
+
+	if htmlBody != "" && len(links) == 0 {
+		for _, m := range linkRe.FindAllString(htmlBody, -1) {
+			add(m)
+		}
+	}
+
+	return links
+}
+
+func parseMessage(raw rawMessage) (*Message, error) {
+	msg := &Message{
+		ID:      raw.ID,
+		From:    raw.From.String(),
+		To:      make([]string, 0, len(raw.To)),
+		Subject: "",
+		Created: raw.Created,
+		Text:    "",
+		HTML:    "",
+		Links:   nil,
+	}
+
+	for _, to := range raw.To {
+		msg.To = append(msg.To, to.String())
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(raw.Raw.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message %s: %w", raw.ID, err)
+	}
+
+	dec := new(mime.WordDecoder)
+
+	msg.Subject = m.Header.Get("Subject")
+	if subject, err := dec.DecodeHeader(msg.Subject); err == nil {
+		msg.Subject = subject
+	}
+
+	if err := msg.addPart(
+		m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body,
+	); err != nil {
+		return nil, fmt.Errorf("failed to parse message %s: %w", raw.ID, err)
+	}
+
+	msg.Links = extractLinks(msg.Text, msg.HTML)
+
+	return msg, nil
+}
@@ -0,0 +1,67 @@
+package mailhog //nolint:testpackage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const multipartMessage = `From: hasura-auth@example.com
+To: user@example.com
+Subject: =?UTF-8?Q?Verify_your_email?=
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="boundary"
+
+--boundary
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Use this link to verify your email: https://local.auth.nhost.run/v1/verify?ticket=3D=
+abc&type=3DemailVerify
+
+--boundary
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: base64
+
+PGEgaHJlZj0iaHR0cHM6Ly9sb2NhbC5hdXRoLm5ob3N0LnJ1bi92MS92ZXJpZnk/dGlja2V0PWFi
+YyZhbXA7dHlwZT1lbWFpbFZlcmlmeSI+VmVyaWZ5PC9hPg==
+--boundary--
+`
+
+func TestParseMessage(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseMessage(rawMessage{
+		ID:      "id1",
+		From:    Path{Mailbox: "hasura-auth", Domain: "example.com"},
+		To:      []Path{{Mailbox: "user", Domain: "example.com"}},
+		Created: created,
+		Raw: Raw{
+			From: "hasura-auth@example.com",
+			To:   []string{"user@example.com"},
+			Data: strings.ReplaceAll(multipartMessage, "\n", "\r\n"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Message{
+		ID:      "id1",
+		From:    "hasura-auth@example.com",
+		To:      []string{"user@example.com"},
+		Subject: "Verify your email",
+		Created: created,
+		Text:    "Use this link to verify your email: https://local.auth.nhost.run/v1/verify?ticket=abc&type=emailVerify\r\n", //nolint:lll
+		HTML:    `<a href="https://local.auth.nhost.run/v1/verify?ticket=abc&amp;type=emailVerify">Verify</a>`,
+		Links:   []string{"https://local.auth.nhost.run/v1/verify?ticket=abc&type=emailVerify"},
+	}
+
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
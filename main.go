@@ -16,6 +16,7 @@ import (
 	"github.com/nhost/cli/cmd/project"
 	"github.com/nhost/cli/cmd/run"
 	"github.com/nhost/cli/cmd/secrets"
+	"github.com/nhost/cli/cmd/smscatcher"
 	"github.com/nhost/cli/cmd/software"
 	"github.com/nhost/cli/cmd/user"
 	"github.com/urfave/cli/v2"
@@ -42,12 +43,14 @@ func main() {
 			dev.CommandUp(),
 			dev.CommandDown(),
 			dev.CommandLogs(),
+			dev.CommandStatus(),
 			dockercredentials.Command(),
 			project.CommandInit(),
 			project.CommandList(),
 			project.CommandLink(),
 			run.Command(),
 			secrets.Command(),
+			smscatcher.Command(),
 			software.Command(),
 			user.CommandLogin(),
 			{
@@ -0,0 +1,219 @@
+package ssl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	caCertFile = "ca.crt"
+	caKeyFile  = "ca.key"
+	caValidity = 10 * 365 * 24 * time.Hour
+	// browsers reject leaf certificates valid for more than 825 days
+	certValidity = 825 * 24 * time.Hour
+)
+
+// CA is a certificate authority generated locally to issue certificates for
+// hosts the bundled certificates don't cover.
+type CA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// CertPath is the path to the PEM encoded certificate of the CA
+	CertPath string
+}
+
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)) //nolint:mnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	return serial, nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), nil //nolint:exhaustruct
+}
+
+func createCA(certPath, keyPath string, permittedDNSDomains []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return err
+	}
+
+	template := &x509.Certificate{ //nolint:exhaustruct
+		SerialNumber: serial,
+		Subject: pkix.Name{ //nolint:exhaustruct
+			Organization: []string{"Nhost CLI"},
+			CommonName:   "Nhost CLI local development CA",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+		// limits the damage if the CA is trusted and its key leaks
+		PermittedDNSDomainsCritical: len(permittedDNSDomains) > 0,
+		PermittedDNSDomains:         permittedDNSDomains,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to write CA key: %w", err)
+	}
+
+	if err := os.WriteFile( //nolint:gosec
+		certPath,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), //nolint:exhaustruct
+		0o644, //nolint:mnd
+	); err != nil {
+		return fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	return nil
+}
+
+func readPEM(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode %s", path) //nolint:err113
+	}
+
+	return block.Bytes, nil
+}
+
+func loadCA(certPath, keyPath string) (*CA, error) {
+	certDER, err := readPEM(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	keyDER, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParseECPrivateKey(keyDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	return &CA{
+		cert:     cert,
+		key:      key,
+		CertPath: certPath,
+	}, nil
+}
+
+// LoadOrCreateCA loads the CA stored in dir, creating it if it doesn't exist.
+// The CA can only issue certificates for permittedDNSDomains and their
+// subdomains, any if none are given. A CA with other constraints, i.e. one
+// created by an older version, is replaced.
+func LoadOrCreateCA(dir string, permittedDNSDomains ...string) (*CA, error) {
+	certPath := filepath.Join(dir, caCertFile)
+	keyPath := filepath.Join(dir, caKeyFile)
+
+	if _, err := os.Stat(certPath); err == nil {
+		ca, err := loadCA(certPath, keyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Equal(ca.cert.PermittedDNSDomains, permittedDNSDomains) {
+			return ca, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("failed to create CA folder: %w", err)
+	}
+
+	if err := createCA(certPath, keyPath, permittedDNSDomains); err != nil {
+		return nil, err
+	}
+
+	return loadCA(certPath, keyPath)
+}
+
+// IssueCert returns a PEM encoded certificate and key for dnsNames signed by the CA.
+func (ca *CA) IssueCert(dnsNames ...string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{ //nolint:exhaustruct
+		SerialNumber: serial,
+		Subject: pkix.Name{ //nolint:exhaustruct
+			Organization: []string{"Nhost CLI"},
+			CommonName:   dnsNames[0],
+		},
+		DNSNames:    dnsNames,
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}) //nolint:exhaustruct
+
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,133 @@
+package ssl_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/nhost/cli/ssl"
+)
+
+func TestIssueCert(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	ca, err := ssl.LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// loading again must reuse the same CA
+	again, err := ssl.LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := again.IssueCert("api.twilio.com", "verify.twilio.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM, err := os.ReadFile(ca.CertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatal("failed to parse CA certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{ //nolint:exhaustruct
+		DNSName: "verify.twilio.com",
+		Roots:   roots,
+	}); err != nil {
+		t.Errorf("certificate not valid for CA: %v", err)
+	}
+}
+
+func TestCAConstraints(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	ca, err := ssl.LoadOrCreateCA(dir, "nhost.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM, err := os.ReadFile(ca.CertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatal("failed to parse CA certificate")
+	}
+
+	cases := []struct {
+		name    string
+		dnsName string
+		valid   bool
+	}{
+		{name: "subdomain", dnsName: "auth.local.nhost.test", valid: true},
+		{name: "other domain", dnsName: "api.twilio.com", valid: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			certPEM, keyPEM, err := ca.IssueCert(tc.dnsName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pair, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			leaf, err := x509.ParseCertificate(pair.Certificate[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = leaf.Verify(x509.VerifyOptions{ //nolint:exhaustruct
+				DNSName: tc.dnsName,
+				Roots:   roots,
+			})
+			if (err == nil) != tc.valid {
+				t.Errorf("expected valid to be %t, got %v", tc.valid, err)
+			}
+		})
+	}
+
+	// a CA with other constraints is replaced
+	other, err := ssl.LoadOrCreateCA(dir, "example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPEM, err := os.ReadFile(other.CertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(otherPEM) == string(caPEM) {
+		t.Error("expected the CA to be recreated")
+	}
+}